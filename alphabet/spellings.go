@@ -0,0 +1,11 @@
+package alphabet
+
+/*
+The letters A through Z are all an Enigma keyboard has.
+German operators spelled out the rest.
+*/
+
+// Spellings are how German plaintext wrote letters Enigma hasn't got
+var Spellings = map[rune]string{
+	'Ä': "AE", 'Ö': "OE", 'Ü': "UE", 'ß': "SS",
+}
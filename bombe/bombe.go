@@ -0,0 +1,255 @@
+package bombe
+
+import (
+	"enigmalike/enigma"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Bombe searches rotor orders of 3 rotors, with the rings at 'A'.
+// Like the real bombe, it takes the middle rotor to stay put for
+// the length of the crib, so a stop's fast rotor position and ring
+// setting together are right, but the middle rotor may step at the
+// wrong letter, and the middle and slow rotors' ring settings are
+// left to find by decrypting.
+type Bombe struct {
+	Reflector string   // "B" if empty, not a thin one
+	Rotors    []string // rotors to search, I through V if nil
+	Workers   int      // rotor orders to run at once, one per CPU if 0
+}
+
+// Stop is a rotor order and starting position where the bombe stopped,
+// with the plugboard partners the menu implies there.
+// Key has the rings at 'A', and the Stecker cables the stop found.
+// Self is the letters the stop says have no cable.
+type Stop struct {
+	Key  *enigma.Key
+	Self string
+}
+
+// positions is how many starting positions 3 rotors have
+const positions = 26 * 26 * 26
+
+// Run tries menu on every rotor order and starting position and
+// returns its stops, in rotor order. As the checking machine did for
+// the real bombe, it leaves out stops whose Stecker partners contradict
+// each other.
+func (b *Bombe) Run(menu *Menu) ([]Stop, error) {
+	reflector := b.Reflector
+	if reflector == "" {
+		reflector = "B"
+	}
+	if enigma.ThinReflectors[reflector] {
+		return nil, fmt.Errorf("%w: the bombe has 3 rotors, reflector %s needs a Greek rotor", enigma.ErrWrongPart, reflector)
+	}
+	rotors := b.Rotors
+	if rotors == nil {
+		rotors = []string{"I", "II", "III", "IV", "V"}
+	}
+	workers := b.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	orders := enigma.RotorOrders(rotors, 3)
+	stops := make([][]Stop, len(orders))
+	errs := make([]error, len(orders))
+	limit := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, order := range orders {
+		wg.Add(1)
+		go func(i int, order []string) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			stops[i], errs[i] = runOrder(reflector, order, menu)
+		}(i, order)
+	}
+	wg.Wait()
+
+	var all []Stop
+	for i := range orders {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		all = append(all, stops[i]...)
+	}
+	return all, nil
+}
+
+// runOrder runs menu on one rotor order
+func runOrder(reflector string, order []string, menu *Menu) ([]Stop, error) {
+	machine, err := enigma.New(enigma.WithReflector(reflector), enigma.WithRotors(order...))
+	if err != nil {
+		return nil, err
+	}
+	if err := machine.Precompute(); err != nil {
+		return nil, err
+	}
+	// the scrambler at every position, the fast rotor's position least significant
+	scramblers := make([][26]int, positions)
+	for state := range scramblers {
+		machine.SetRotors(positionLetters(state))
+		scramblers[state] = machine.Scrambler()
+	}
+
+	var stops []Stop
+	var t tester
+	t.menu = menu
+	t.adjacent = adjacency(menu)
+	t.scramblers = make([]*[26]int, len(menu.Edges))
+	for state := 0; state < positions; state++ {
+		fast, rest := state%26, state-state%26
+		for i, e := range menu.Edges {
+			// the rotors step before each letter
+			t.scramblers[i] = &scramblers[rest+(fast+e.Offset+1)%26]
+		}
+		for _, partners := range t.stop() {
+			stops = append(stops, newStop(reflector, order, positionLetters(state), partners))
+		}
+	}
+	return stops, nil
+}
+
+// positionLetters is the rotor settings, fast rotor first, of a state
+func positionLetters(state int) string {
+	return string([]byte{byte(state%26 + 'A'), byte(state/26%26 + 'A'), byte(state/676 + 'A')})
+}
+
+// link is an edge of the menu from one of its letters
+type link struct {
+	other int // the letter at the other end
+	edge  int // index into Menu.Edges
+}
+
+func adjacency(menu *Menu) [26][]link {
+	var adjacent [26][]link
+	for i, e := range menu.Edges {
+		a, b := int(e.Plain-'A'), int(e.Cipher-'A')
+		adjacent[a] = append(adjacent[a], link{b, i})
+		adjacent[b] = append(adjacent[b], link{a, i})
+	}
+	return adjacent
+}
+
+// tester is the bombe's electrics for one menu at one position.
+// Bit y of live[x] is the guess that x's plugboard partner is y.
+// Current from one guess flows through each of x's edges' scramblers
+// to make the guesses it implies live, and through the diagonal board,
+// which makes y's partner x live too.
+type tester struct {
+	menu       *Menu
+	adjacent   [26][]link
+	scramblers []*[26]int // for each edge, at this position
+	live       [26]uint32
+	queue      []int // guesses to follow, 26*x + y
+}
+
+const allLive = 1<<26 - 1
+
+// stop tests the guess that the menu's test letter has partner 'A',
+// the way the bombe did, and returns the plugboard partners of each
+// stop, nil unless the position is one. If every partner of the test
+// letter lights up, every guess is wrong, the usual case. If just
+// one does, it's the one guess that might be right. If some but not
+// all do, the guesses that don't might be.
+func (t *tester) stop() [][26]int {
+	test := int(t.menu.Test - 'A')
+	if !t.close(test, 0, true) {
+		return nil
+	}
+
+	candidates := []int{0}
+	if bits.OnesCount32(t.live[test]) > 1 {
+		candidates = nil
+		for y := 0; y < 26; y++ {
+			if t.live[test]&(1<<y) == 0 {
+				candidates = append(candidates, y)
+			}
+		}
+	}
+
+	var stops [][26]int
+	for _, y := range candidates {
+		t.close(test, y, false)
+		if partners, ok := t.partners(); ok {
+			stops = append(stops, partners)
+		}
+	}
+	return stops
+}
+
+// close makes the guess that x's partner is y live, and every guess
+// that follows from it. With giveUp, it stops once all of the test
+// letter's guesses are live and reports false.
+func (t *tester) close(x, y int, giveUp bool) bool {
+	test := int(t.menu.Test - 'A')
+	t.live = [26]uint32{}
+	t.queue = t.queue[:0]
+	t.light(x, y)
+	for len(t.queue) > 0 {
+		guess := t.queue[len(t.queue)-1]
+		t.queue = t.queue[:len(t.queue)-1]
+		x, y := guess/26, guess%26
+		for _, l := range t.adjacent[x] {
+			t.light(l.other, t.scramblers[l.edge][y])
+		}
+		if giveUp && t.live[test] == allLive {
+			return false
+		}
+	}
+	return true
+}
+
+// light makes a guess and its diagonal live, queueing them to follow
+func (t *tester) light(x, y int) {
+	if t.live[x]&(1<<y) == 0 {
+		t.live[x] |= 1 << y
+		t.queue = append(t.queue, 26*x+y)
+	}
+	if t.live[y]&(1<<x) == 0 {
+		t.live[y] |= 1 << x
+		t.queue = append(t.queue, 26*y+x)
+	}
+}
+
+// partners reads the plugboard partners off the live guesses,
+// -1 for letters the guesses don't reach. They're consistent if
+// each letter reached has just one partner.
+func (t *tester) partners() ([26]int, bool) {
+	var partners [26]int
+	for x, live := range t.live {
+		switch bits.OnesCount32(live) {
+		case 0:
+			partners[x] = -1
+		case 1:
+			partners[x] = bits.TrailingZeros32(live)
+		default:
+			return partners, false
+		}
+	}
+	return partners, true
+}
+
+func newStop(reflector string, order []string, positions string, partners [26]int) Stop {
+	key := &enigma.Key{
+		Stepping:  enigma.DoubleStepping,
+		Reflector: reflector,
+		Rotors:    append([]string(nil), order...),
+		Rings:     "AAA",
+		Positions: positions,
+	}
+	var self []string
+	for x, y := range partners {
+		switch {
+		case y == x:
+			self = append(self, string(rune(x+'A')))
+		case y > x:
+			key.Plugs = append(key.Plugs, string([]rune{rune(x + 'A'), rune(y + 'A')}))
+		}
+	}
+	return Stop{Key: key, Self: strings.Join(self, "")}
+}
@@ -0,0 +1,210 @@
+package bombe
+
+/*
+A Turing-Welchman bombe: given a crib, plaintext the ciphertext is
+guessed to contain at some offset, it tries every rotor order and
+starting position, and stops at the ones where some plugboard could
+make the crib encrypt to the ciphertext. The crib's letter pairs
+make a graph, the menu, with an edge for each keypress: the scrambler
+at that keypress joins the plugboard partners of its two letters.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Errors NewMenu wraps, so callers can check for them with errors.Is
+var (
+	ErrCribTooLong = errors.New("crib runs past the end of the ciphertext")
+	ErrCrash       = errors.New("crib letter encrypts to itself")
+	ErrNotLetter   = errors.New("not a letter 'A' through 'Z'")
+)
+
+// Edge is a letter of the crib and the ciphertext letter under it,
+// which encrypt to each other at keypress Offset, 0 for the first
+// letter of the message
+type Edge struct {
+	Plain, Cipher rune
+	Offset        int
+}
+
+// Menu is the graph of a crib's letter pairs.
+// Test is the letter with the most edges, the bombe's test register.
+type Menu struct {
+	Edges []Edge
+	Test  rune
+}
+
+// NewMenu makes the menu of crib, placed at offset in ciphertext,
+// both uppercase letters 'A' through 'Z'. Enigma never encrypts
+// a letter to itself, so a crib letter over the same ciphertext
+// letter is a crash, and can't be the right place for the crib.
+func NewMenu(ciphertext, crib []rune, offset int) (*Menu, error) {
+	if offset < 0 || offset+len(crib) > len(ciphertext) {
+		return nil, fmt.Errorf("offset %d: %w", offset, ErrCribTooLong)
+	}
+	return newMenu(crib, ciphertext[offset:offset+len(crib)], offset)
+}
+
+// newMenu makes the menu of crib over under, the ciphertext
+// from offset on
+func newMenu(crib, under []rune, offset int) (*Menu, error) {
+	if len(crib) == 0 {
+		return nil, errors.New("empty crib")
+	}
+
+	m := &Menu{}
+	var edges [26]int
+	for i, plain := range crib {
+		cipher := under[i]
+		for _, r := range []rune{plain, cipher} {
+			if r < 'A' || r > 'Z' {
+				return nil, fmt.Errorf("%q at crib letter %d: %w", r, i+1, ErrNotLetter)
+			}
+		}
+		if plain == cipher {
+			return nil, fmt.Errorf("%c at crib letter %d: %w", plain, i+1, ErrCrash)
+		}
+		m.Edges = append(m.Edges, Edge{Plain: plain, Cipher: cipher, Offset: offset + i})
+		edges[plain-'A']++
+		edges[cipher-'A']++
+	}
+
+	for i, count := range edges {
+		if m.Test == 0 || count > edges[m.Test-'A'] {
+			m.Test = rune(i + 'A')
+		}
+	}
+	return m, nil
+}
+
+// Placements lists the offsets in ciphertext where crib fits
+// without a crash
+func Placements(ciphertext, crib []rune) []int {
+	var offsets []int
+	for offset := 0; offset+len(crib) <= len(ciphertext); offset++ {
+		crash := false
+		for i, plain := range crib {
+			if ciphertext[offset+i] == plain {
+				crash = true
+				break
+			}
+		}
+		if !crash {
+			offsets = append(offsets, offset)
+		}
+	}
+	return offsets
+}
+
+// MarshalText writes the target Menu as its first offset,
+// the crib and the ciphertext under it, like
+// "12 WETTERVORHERSAGE KIQSJTHYTBACTSXJ", which is all
+// cmd/bombe.go needs to run it. The Menu must come from one crib.
+func (m *Menu) MarshalText() ([]byte, error) {
+	if len(m.Edges) == 0 {
+		return nil, errors.New("empty menu")
+	}
+	var crib, under []rune
+	for i, e := range m.Edges {
+		if e.Offset != m.Edges[0].Offset+i {
+			return nil, errors.New("menu isn't from one crib")
+		}
+		crib = append(crib, e.Plain)
+		under = append(under, e.Cipher)
+	}
+	return []byte(fmt.Sprintf("%d %s %s", m.Edges[0].Offset, string(crib), string(under))), nil
+}
+
+// UnmarshalText reads a Menu that MarshalText wrote
+func (m *Menu) UnmarshalText(text []byte) error {
+	fields := strings.Fields(string(text))
+	if len(fields) != 3 || len(fields[1]) != len(fields[2]) {
+		return fmt.Errorf("menu %q isn't an offset, a crib and the ciphertext under it", text)
+	}
+	offset, err := strconv.Atoi(fields[0])
+	if err != nil || offset < 0 {
+		return fmt.Errorf("menu %q: bad offset", text)
+	}
+	menu, err := newMenu([]rune(fields[1]), []rune(fields[2]), offset)
+	if err != nil {
+		return fmt.Errorf("menu %q: %w", text, err)
+	}
+	*m = *menu
+	return nil
+}
+
+// Letters counts the letters the target Menu's edges join
+func (m *Menu) Letters() int {
+	var seen [26]bool
+	count := 0
+	for _, e := range m.Edges {
+		for _, r := range []rune{e.Plain, e.Cipher} {
+			if !seen[r-'A'] {
+				seen[r-'A'] = true
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Loops counts the independent closed loops joined to the target
+// Menu's test letter. Each loop is a check on the bombe's guesses,
+// so the more loops, the fewer false stops; loops elsewhere in the
+// menu aren't tested, so they don't count.
+func (m *Menu) Loops() int {
+	var parent [26]int
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, e := range m.Edges {
+		parent[find(int(e.Plain-'A'))] = find(int(e.Cipher - 'A'))
+	}
+
+	// a connected graph has as many independent loops as it has
+	// edges beyond the letters-1 that join its letters
+	test := find(int(m.Test - 'A'))
+	edges, letters := 0, 0
+	for _, e := range m.Edges {
+		if find(int(e.Plain-'A')) == test {
+			edges++
+		}
+	}
+	for i := range parent {
+		if find(i) == test {
+			letters++
+		}
+	}
+	return edges - letters + 1
+}
+
+// Connected counts the letters joined to the target Menu's test letter.
+// The bombe only tests those, the rest of the menu doesn't help.
+func (m *Menu) Connected() int {
+	var seen [26]bool
+	seen[m.Test-'A'] = true
+	count := 1
+	for grew := true; grew; {
+		grew = false
+		for _, e := range m.Edges {
+			a, b := e.Plain-'A', e.Cipher-'A'
+			if seen[a] != seen[b] {
+				seen[a], seen[b] = true, true
+				count++
+				grew = true
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,29 @@
+package bombe
+
+import "testing"
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		crib, under string
+		test        rune
+		want        int
+	}{
+		// A-B-C-A is a loop through the test letter
+		{"ABC", "BCA", 'A', 1},
+		// the loop D-E-F-D isn't joined to the test letter
+		{"ABDEF", "BCEFD", 'A', 0},
+		{"ABDEF", "BCEFD", 'D', 1},
+		// two edges between the same letters make a loop
+		{"AB", "BA", 'B', 1},
+	}
+	for _, tt := range tests {
+		m, err := newMenu([]rune(tt.crib), []rune(tt.under), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Test = tt.test
+		if got := m.Loops(); got != tt.want {
+			t.Errorf("%s over %s, test letter %c: %d loops, want %d", tt.crib, tt.under, tt.test, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+/*
+ * Run a Turing-Welchman bombe on a crib:
+ * ./bombe -c WETTERVORHERSAGE -o 0 ciphertext.txt
+ *
+ * or on menus cmd/cribs.go wrote, one a line:
+ * ./bombe -m menus.txt
+ *
+ * Prints a key string for each stop, rings at 01, the Stecker
+ * pairs the stop implies, and the letters it says have no cable.
+ */
+
+import (
+	"enigmalike/bombe"
+	"enigmalike/enigma"
+	"enigmalike/stats"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+)
+
+func main() {
+	reflector := flag.String("U", "B", "reflector: A, B or C")
+	definitions := flag.String("C", "", "JSON file of extra rotors, reflectors, entry wheels and models")
+	rotorList := flag.String("r", "I,II,III,IV,V", "comma-separated rotors to search, I,II,III,IV,V,VI,VII,VIII for naval")
+	workers := flag.Int("w", runtime.GOMAXPROCS(0), "how many rotor orders to run at once")
+	crib := flag.String("c", "", "crib, plaintext guessed to be in the message")
+	offset := flag.Int("o", 0, "where the crib starts in the ciphertext, 0 for the first letter")
+	menuFileName := flag.String("m", "", "file of menus from cmd/cribs.go to run, instead of -c, -o and a ciphertext file")
+	flag.Parse()
+
+	if *definitions != "" {
+		if err := enigma.LoadDefinitionsFile(*definitions); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var menus []*bombe.Menu
+	switch {
+	case *menuFileName != "":
+		buffer, err := os.ReadFile(*menuFileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, line := range strings.Split(string(buffer), "\n") {
+			if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			menu := &bombe.Menu{}
+			if err := menu.UnmarshalText([]byte(line)); err != nil {
+				log.Fatal(err)
+			}
+			menus = append(menus, menu)
+		}
+	case *crib != "" && flag.NArg() > 0:
+		buffer, err := os.ReadFile(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		ciphertext := stats.Letters(string(buffer))
+
+		menu, err := bombe.NewMenu(ciphertext, stats.Letters(*crib), *offset)
+		if err != nil {
+			log.Fatal(err)
+		}
+		menus = append(menus, menu)
+	default:
+		log.Fatalf("usage: %s -c crib [-o offset] ciphertext file, or %s -m menu file\n", os.Args[0], os.Args[0])
+	}
+
+	b := &bombe.Bombe{Reflector: *reflector, Rotors: strings.Split(*rotorList, ","), Workers: *workers}
+	for _, menu := range menus {
+		text, _ := menu.MarshalText()
+		fmt.Fprintf(os.Stderr, "menu %s: %d letters, %d loops, test letter %c joined to %d\n",
+			text, menu.Letters(), menu.Loops(), menu.Test, menu.Connected())
+		if len(menus) > 1 {
+			fmt.Printf("# %s\n", text)
+		}
+
+		stops, err := b.Run(menu)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, stop := range stops {
+			fmt.Printf("%s\t%s\n", stop.Key, stop.Self)
+		}
+		fmt.Fprintf(os.Stderr, "%d stops\n", len(stops))
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+/*
+ * Find where cribs can go in a ciphertext:
+ * ./cribs -c WETTERVORHERSAGE,KEINEBESONDERENEREIGNISSE ciphertext.txt
+ *
+ * Enigma never encrypts a letter to itself, so a crib can't sit
+ * anywhere a crib letter is over the same ciphertext letter.
+ * Prints the placements that are left, best menus first,
+ * and with -m writes their menus for cmd/bombe.go -m.
+ */
+
+import (
+	"enigmalike/bombe"
+	"enigmalike/stats"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// placement is a crib at one offset, and its menu
+type placement struct {
+	crib   string
+	offset int
+	menu   *bombe.Menu
+}
+
+func main() {
+	cribList := flag.String("c", "", "comma-separated cribs")
+	first := flag.Int("first", 0, "only offsets before this, for cribs that start a message, 0 for anywhere")
+	keep := flag.Int("n", 0, "how many of the best placements to print, 0 for all")
+	menuFileName := flag.String("m", "", "file to write the printed placements' menus to, for cmd/bombe.go -m")
+	flag.Parse()
+
+	if *cribList == "" || flag.NArg() == 0 {
+		log.Fatalf("usage: %s -c crib,crib... ciphertext file\n", os.Args[0])
+	}
+
+	buffer, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ciphertext := stats.Letters(string(buffer))
+
+	var cribs [][]rune
+	for _, crib := range strings.Split(*cribList, ",") {
+		letters := stats.Letters(crib)
+		if len(letters) == 0 {
+			log.Fatalf("crib %q has no letters\n", crib)
+		}
+		cribs = append(cribs, letters)
+	}
+
+	var placements []placement
+	for _, letters := range cribs {
+		crib := string(letters)
+		offsets := bombe.Placements(ciphertext, letters)
+		fmt.Fprintf(os.Stderr, "%s: %d of %d offsets\n", crib, len(offsets), len(ciphertext)-len(letters)+1)
+		for _, offset := range offsets {
+			if *first > 0 && offset >= *first {
+				break
+			}
+			menu, err := bombe.NewMenu(ciphertext, letters, offset)
+			if err != nil {
+				log.Fatal(err)
+			}
+			placements = append(placements, placement{crib: crib, offset: offset, menu: menu})
+		}
+	}
+
+	// loops through the test letter weed out false stops, letters joined
+	// to it make the bombe test more, and a longer crib has more to check
+	sort.SliceStable(placements, func(i, j int) bool {
+		a, b := placements[i].menu, placements[j].menu
+		if a.Loops() != b.Loops() {
+			return a.Loops() > b.Loops()
+		}
+		if a.Connected() != b.Connected() {
+			return a.Connected() > b.Connected()
+		}
+		return len(a.Edges) > len(b.Edges)
+	})
+	if *keep > 0 && len(placements) > *keep {
+		placements = placements[:*keep]
+	}
+
+	var menus []string
+	for _, p := range placements {
+		fmt.Printf("%d\t%d\t%d\t%d\t%s\n", p.offset, p.menu.Loops(), p.menu.Connected(), len(p.menu.Edges), p.crib)
+		text, err := p.menu.MarshalText()
+		if err != nil {
+			log.Fatal(err)
+		}
+		menus = append(menus, string(text))
+	}
+
+	if *menuFileName != "" {
+		header := fmt.Sprintf("# menus for %s, best first: offset, crib, ciphertext under it\n", flag.Arg(0))
+		if err := os.WriteFile(*menuFileName, []byte(header+strings.Join(menus, "\n")+"\n"), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -1,82 +1,404 @@
 package main
 
 import (
+	"container/heap"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"enigmalike/cryptanalysis"
 	"enigmalike/enigma"
+	"enigmalike/stats"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
+	"math/rand"
 	"os"
-	"unicode"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 func main() {
-	buffer, err := os.ReadFile(os.Args[1])
+	reflector := flag.String("U", "B", "reflector: A, B or C")
+	definitions := flag.String("C", "", "JSON file of extra rotors, reflectors, entry wheels and models")
+	rotorList := flag.String("r", "I,II,III,IV,V", "comma-separated rotors to search, I,II,III,IV,V,VI,VII,VIII for naval")
+	workers := flag.Int("w", runtime.GOMAXPROCS(0), "how many settings to try at once")
+	keep := flag.Int("n", 100, "how many of the best rotor orders and settings to print")
+	checkpointName := flag.String("checkpoint", "", "file to save progress in, and resume from if it exists")
+	progressEvery := flag.Duration("progress", 5*time.Second, "how often to report progress on stderr, 0 for never")
+	steppingName := flag.String("step", "double", "rotor stepping model, double, notch, cog or legacy")
+	carry := flag.Int("k", 10, "how many of the best to carry through the ring setting and plugboard stages, 0 for none")
+	maxPlugs := flag.Int("plugs", 10, "most plugboard pairs to look for, up to 13")
+	corpusName := flag.String("T", "", "text file in the plaintext language, or n-gram tables from cmd/ngrams.go, for hill-climbing on n-gram scores after IC")
+	ngramSizes := flag.String("ngrams", "2,3", "comma-separated n-gram sizes to hill-climb on in turn, with -T")
+	restarts := flag.Int("restarts", 4, "hill-climbs from random plugboards for each candidate, after the first")
+	flag.Parse()
+
+	if *definitions != "" {
+		if err := enigma.LoadDefinitionsFile(*definitions); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if enigma.ThinReflectors[*reflector] {
+		log.Fatalf("the search is over 3 rotors, reflector %s needs a Greek rotor\n", *reflector)
+	}
+	if *maxPlugs < 0 || *maxPlugs > enigma.MaxCables {
+		log.Fatalf("-plugs %d, a plugboard takes 0 to %d\n", *maxPlugs, enigma.MaxCables)
+	}
+	if *workers < 1 || *keep < 1 {
+		log.Fatalf("need at least 1 worker and 1 result\n")
+	}
+	stepping, ok := enigma.Steppings[*steppingName]
+	if !ok {
+		log.Fatalf("unknown stepping model %q\n", *steppingName)
+	}
+	var ngrams []cryptanalysis.Fitness
+	if *corpusName != "" {
+		var sizes []int
+		for _, size := range strings.Split(*ngramSizes, ",") {
+			n, err := strconv.Atoi(size)
+			if err != nil {
+				log.Fatalf("bad n-gram size %q\n", size)
+			}
+			sizes = append(sizes, n)
+		}
+		tables, err := loadNGrams(*corpusName, sizes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, n := range sizes {
+			table := stats.Find(tables, n)
+			if table == nil {
+				log.Fatalf("%s has no %d-grams\n", *corpusName, n)
+			}
+			ngrams = append(ngrams, table)
+		}
+	}
+
+	buffer, err := os.ReadFile(flag.Arg(0))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	inputText := convertBuffer(buffer)
+	inputText := stats.Letters(string(buffer))
 
-	rotorNames := []string{"I", "II", "III", "IV", "V"}
+	rotorNames := strings.Split(*rotorList, ",")
+	if len(rotorNames) < 3 {
+		log.Fatalf("the search is over 3 rotors, -r only has %d\n", len(rotorNames))
+	}
+	orders := enigma.RotorOrders(rotorNames, 3)
 
-	for i := 0; i < 5; i++ {
-		for j := 0; j < 5; j++ {
-			if i == j {
-				continue
+	// Each unit of work is a rotor order with the slow rotor at one letter,
+	// 676 settings of the fast and middle rotors.
+	hash := sha256.Sum256([]byte(string(inputText)))
+	search := &checkpoint{
+		Reflector: *reflector,
+		Stepping:  *steppingName,
+		Rotors:    rotorNames,
+		Text:      hex.EncodeToString(hash[:]),
+		Done:      make([]bool, len(orders)*26),
+	}
+	if *checkpointName != "" {
+		if err := search.resume(*checkpointName); err != nil {
+			log.Fatal(err)
+		}
+		for search.Best.Len() > *keep {
+			heap.Pop(&search.Best)
+		}
+	}
+
+	var todo []int
+	for unit, done := range search.Done {
+		if !done {
+			todo = append(todo, unit)
+		}
+	}
+
+	units := make(chan int)
+	results := make(chan unitResult)
+	for w := 0; w < *workers; w++ {
+		go func() {
+			for unit := range units {
+				result := tryUnit(*reflector, stepping, orders[unit/26], unit%26, inputText, *keep)
+				result.unit = unit
+				results <- result
 			}
-			for k := 0; k < 5; k++ {
-				if i == k || j == k {
-					continue
+		}()
+	}
+	go func() {
+		for _, unit := range todo {
+			units <- unit
+		}
+		close(units)
+	}()
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+
+	var progress <-chan time.Time
+	if *progressEvery > 0 {
+		ticker := time.NewTicker(*progressEvery)
+		defer ticker.Stop()
+		progress = ticker.C
+	}
+	saved := time.Now()
+	started := time.Now()
+
+	for finished := 0; finished < len(todo); {
+		select {
+		case result := <-results:
+			if result.err != nil {
+				log.Fatal(result.err)
+			}
+			for _, c := range result.best {
+				search.Best.keep(c, *keep)
+			}
+			search.Done[result.unit] = true
+			finished++
+			if *checkpointName != "" && time.Since(saved) > 10*time.Second {
+				if err := search.save(*checkpointName); err != nil {
+					log.Fatal(err)
 				}
-				machine := enigma.NewMachine(rotorNames[i], rotorNames[j], rotorNames[k])
-
-				for ring1 := 0; ring1 < 26; ring1++ {
-					for ring2 := 0; ring2 < 26; ring2++ {
-						for ring3 := 0; ring3 < 26; ring3++ {
-							ringSettings := fmt.Sprintf("%c%c%c", ring1+'A', ring2+'A', ring3+'A')
-							machine.SetRotors(ringSettings)
-							outputText := machine.EncryptBuffer(inputText)
-							N, ic := indexOfCoincidence(outputText)
-							fmt.Printf("%.05f\t%d\t%s\t%s\t%s\t%s\n", ic, N, rotorNames[i], rotorNames[j], rotorNames[k], ringSettings)
-						}
-					}
+				saved = time.Now()
+			}
+		case <-progress:
+			reportProgress(len(search.Done)-len(todo)+finished, len(search.Done), finished, started)
+		case <-interrupted:
+			if *checkpointName != "" {
+				if err := search.save(*checkpointName); err != nil {
+					log.Fatal(err)
 				}
+				fmt.Fprintf(os.Stderr, "saved progress in %s\n", *checkpointName)
 			}
+			os.Exit(1)
 		}
 	}
+
+	if *checkpointName != "" {
+		if err := search.save(*checkpointName); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	best := append(candidates(nil), search.Best...)
+	sort.Slice(best, func(i, j int) bool { return best[i].IC > best[j].IC })
+	for _, c := range best {
+		fmt.Printf("%.05f\t%d\t%s\t%s\n", c.IC, len(inputText), strings.Join(c.Rotors, "\t"), c.Setting)
+	}
+
+	if len(best) == 0 {
+		fmt.Fprintln(os.Stderr, "no rotor order or setting to carry on with")
+		return
+	}
+	if *carry < 1 {
+		return
+	}
+	if *carry > len(best) {
+		*carry = len(best)
+	}
+
+	// Weierud and Sullivan's hill-climbing of rings and plugboard, on the best few
+	stages := []cryptanalysis.Fitness{cryptanalysis.IC}
+	stages = append(stages, ngrams...)
+
+	recovered := make([]recovery, *carry)
+	limit := make(chan struct{}, *workers)
+	var wg sync.WaitGroup
+	for i, c := range best[:*carry] {
+		wg.Add(1)
+		go func(i int, c candidate) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			climber := &cryptanalysis.Climber{
+				MaxPlugs: *maxPlugs,
+				Restarts: *restarts,
+				Rand:     rand.New(rand.NewSource(int64(i))),
+			}
+			key := &enigma.Key{Stepping: stepping, Reflector: *reflector, Rotors: c.Rotors, Rings: "AAA", Positions: c.Setting}
+			var err error
+			recovered[i].key, recovered[i].score, err = climber.Climb(key, inputText, stages...)
+			if err != nil {
+				log.Fatal(err)
+			}
+			recovered[i].plaintext = decrypt(recovered[i].key, inputText)
+		}(i, c)
+	}
+	wg.Wait()
+
+	sort.SliceStable(recovered, func(i, j int) bool { return recovered[i].score > recovered[j].score })
+
+	fmt.Println()
+	for _, r := range recovered {
+		fmt.Printf("%.05f\t%s\n", stats.IndexOfCoincidence(r.plaintext), r.key)
+	}
+	fmt.Println()
+	fmt.Println(string(recovered[0].plaintext))
+}
+
+// recovery is a key the hill-climbing found, and what it decrypts to
+type recovery struct {
+	key       *enigma.Key
+	score     float64 // by the last hill-climbing stage
+	plaintext []rune
+}
+
+func decrypt(key *enigma.Key, text []rune) []rune {
+	machine, err := enigma.New(enigma.WithKey(key))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return machine.EncryptBuffer(text)
 }
 
-func convertBuffer(buffer []byte) []rune {
-	var upperCaseLetters []rune
+// unitResult is the best candidates of one unit of work
+type unitResult struct {
+	unit int
+	best candidates
+	err  error
+}
 
-	for _, letter := range string(buffer) {
-		if !unicode.IsLetter(letter) {
-			continue
+// tryUnit decrypts with every setting of the fast and middle rotors
+// of one rotor order, with the slow rotor at slow, and keeps the best
+func tryUnit(reflector string, stepping enigma.Stepping, order []string, slow int, inputText []rune, keep int) unitResult {
+	var result unitResult
+	machine, err := enigma.New(enigma.WithReflector(reflector), enigma.WithRotors(order...), enigma.WithStepping(stepping))
+	if err != nil {
+		result.err = err
+		return result
+	}
+	// one table covers every setting of this rotor order
+	if err := machine.Precompute(); err != nil {
+		result.err = err
+		return result
+	}
+
+	for fast := 0; fast < 26; fast++ {
+		for middle := 0; middle < 26; middle++ {
+			settings := fmt.Sprintf("%c%c%c", fast+'A', middle+'A', slow+'A')
+			machine.SetRotors(settings)
+			outputText := machine.EncryptBuffer(inputText)
+			ic := stats.IndexOfCoincidence(outputText)
+			result.best.keep(candidate{IC: ic, Rotors: order, Setting: settings}, keep)
 		}
-		upperCaseLetters = append(upperCaseLetters, unicode.ToUpper(letter))
 	}
+	return result
+}
 
-	return upperCaseLetters
+// reportProgress writes how far the search has got, and how long
+// it should take to finish at the rate of this run, to stderr
+func reportProgress(done, total, doneThisRun int, started time.Time) {
+	elapsed := time.Since(started)
+	eta := "unknown"
+	if doneThisRun > 0 {
+		remaining := time.Duration(float64(elapsed) / float64(doneThisRun) * float64(total-done))
+		eta = remaining.Round(time.Second).String()
+	}
+	fmt.Fprintf(os.Stderr, "%d/%d (%.1f%%) elapsed %v, ETA %s\n",
+		done, total, 100*float64(done)/float64(total), elapsed.Round(time.Second), eta)
 }
 
-// indexOfCoincidence examines the formal argument string
-// (which should be uppercase unicode letters, 'A' - 'Z')
-// counts the letters in the string, and returns a count of
-// letters and the index of coincidence of that string
-func indexOfCoincidence(buffer []rune) (int, float64) {
-	N := 0
-	var frequencies [26]int
+// candidate is a rotor order and setting, fast rotor first,
+// and the index of coincidence of its decryption
+type candidate struct {
+	IC      float64  `json:"ic"`
+	Rotors  []string `json:"rotors"`
+	Setting string   `json:"setting"`
+}
 
-	for _, r := range string(buffer) {
-		frequencies[int(r-'A')]++
-		N++
+// candidates is a min-heap on IC, so the worst candidate kept is at [0]
+type candidates []candidate
+
+func (h candidates) Len() int           { return len(h) }
+func (h candidates) Less(i, j int) bool { return h[i].IC < h[j].IC }
+func (h candidates) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *candidates) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *candidates) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// keep adds c to the target candidates if it's one of the n best so far
+func (h *candidates) keep(c candidate, n int) {
+	if h.Len() < n {
+		heap.Push(h, c)
+		return
 	}
+	if c.IC > (*h)[0].IC {
+		(*h)[0] = c
+		heap.Fix(h, 0)
+	}
+}
 
-	sum := 0
-	for _, freq := range frequencies {
-		sum += freq * (freq - 1)
+// checkpoint is a search's progress, saved as JSON so a run can resume
+type checkpoint struct {
+	Reflector string     `json:"reflector"`
+	Stepping  string     `json:"stepping"`
+	Rotors    []string   `json:"rotors"`
+	Text      string     `json:"text_sha256"`
+	Done      []bool     `json:"done"` // a unit of work for each rotor order and slow rotor letter
+	Best      candidates `json:"best"`
+}
+
+// resume reads a checkpoint file saved by a search of the same
+// text, reflector and rotors into the target checkpoint.
+// No file yet is a new search.
+func (c *checkpoint) resume(name string) error {
+	buffer, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	ic := float64(sum) / float64(N*(N-1))
+	var saved checkpoint
+	if err := json.Unmarshal(buffer, &saved); err != nil {
+		return fmt.Errorf("checkpoint %s: %w", name, err)
+	}
+	if saved.Reflector != c.Reflector || saved.Stepping != c.Stepping || strings.Join(saved.Rotors, ",") != strings.Join(c.Rotors, ",") ||
+		saved.Text != c.Text || len(saved.Done) != len(c.Done) {
+		return fmt.Errorf("checkpoint %s is for a different search", name)
+	}
+	heap.Init(&saved.Best)
+	*c = saved
 
-	return N, ic
+	return nil
+}
+
+// save writes the target checkpoint to a file, by way of a temporary
+// file, so a kill part way through leaves the last checkpoint
+func (c *checkpoint) save(name string) error {
+	buffer, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(name+".tmp", buffer, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// loadNGrams reads an n-gram tables file, or counts the n-grams
+// of each of sizes in a plaintext file. A broken tables file
+// is an error, not plaintext.
+func loadNGrams(name string, sizes []int) ([]*stats.Table, error) {
+	tables, err := stats.LoadTables(name)
+	if !errors.Is(err, stats.ErrNotTables) {
+		return tables, err
+	}
+	corpus, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return stats.NewTables(stats.Letters(string(corpus)), sizes...)
 }
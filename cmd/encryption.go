@@ -1,60 +1,174 @@
 package main
 
 import (
+	"bufio"
 	"enigmalike/enigma"
+	"enigmalike/rotor"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
-	"unicode"
 )
 
 func main() {
 	inFileName := flag.String("i", "", "input file name")
+	definitions := flag.String("C", "", "JSON file of extra rotors, reflectors, entry wheels and models")
+	modelName := flag.String("M", "", "Enigma model preset: I, M4, D, K, SwissK or G")
 	first := flag.String("1", "I", "first rotor")
 	second := flag.String("2", "II", "second rotor")
 	third := flag.String("3", "III", "third rotor")
-	settings := flag.String("S", "AAA", "initial rotor settings")
+	greek := flag.String("4", "", "Greek rotor, Beta or Gamma, for a 4-rotor M4 with a thin reflector")
+	rotorList := flag.String("r", "", "comma-separated rotors, fast rotor first, any number, instead of -1, -2 and -3")
+	reflector := flag.String("U", "", "reflector: A, B, C, B-thin, C-thin, K or G (default B, or the model's)")
+	reflectorSetting := flag.String("u", "", "reflector position letter, for commercial models")
+	entryWheel := flag.String("E", "", "entry wheel: ABC or QWERTZ (default ABC, or the model's)")
+	ukwd := flag.String("D", "", "comma-separated UKW-D wiring, 12 pairs not using J or Y, replaces -U")
+	settings := flag.String("S", "", "initial rotor settings, fast rotor first, A for any left out")
+	rings := flag.String("R", "", "rotor ring settings, fast rotor first, A for any left out")
 	plugs := flag.String("P", "", "comma-separated plugboard settings")
+	uhr := flag.Int("uhr", -1, "Uhr switch setting 0-39, plugs the 10 -P pairs into the Uhr")
+	keyString := flag.String("K", "", "key string like \"B I-II-III 01-01-01 ABC AB CD EF\", slow rotor first, instead of -U, -1, -2, -3, -r, -S, -R and -P")
+	stepping := flag.String("step", "", "rotor stepping model, notch, double, cog or legacy (default double, or the model's or key's)")
+	skip := flag.Int("skip", 0, "start this many keypresses into the message, for a fragment")
+	nonLetters := flag.String("n", "drop", "non-letters: drop, pass (unencrypted) or translit (Ä as AE, digits as words...)")
 	flag.Parse()
 
-	var cleartext string
+	if *uhr >= 0 && *plugs == "" {
+		log.Fatal("-uhr needs the 10 plug pairs it takes in -P")
+	}
+	if *greek != "" && *rotorList != "" {
+		log.Fatal("-4 goes with -1, -2 and -3, not -r")
+	}
 
+	if *definitions != "" {
+		if err := enigma.LoadDefinitionsFile(*definitions); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	mode, ok := enigma.NonLetterModes[*nonLetters]
+	if !ok {
+		log.Fatalf("unknown non-letter handling %q\n", *nonLetters)
+	}
+
+	var input io.Reader = strings.NewReader("")
 	if *inFileName != "" {
-		if buffer, err := os.ReadFile(*inFileName); err != nil {
+		fin, err := os.Open(*inFileName)
+		if err != nil {
 			log.Fatal(err)
-		} else {
-			cleartext = string(buffer)
 		}
+		defer fin.Close()
+		input = fin
 	} else {
 		// no input file, read a string from the command line
 		if flag.NArg() > 0 {
-			cleartext = flag.Arg(0)
+			input = strings.NewReader(flag.Arg(0))
 		}
 	}
 
-	machine := enigma.NewMachine(*first, *second, *third)
-	machine.SetRotors(*settings)
-
-	if len(*plugs) > 0 {
-		swaps := strings.Split(*plugs, ",")
-		machine.Plugboard(swaps...)
+	var rotors []string
+	if *rotorList != "" {
+		rotors = strings.Split(*rotorList, ",")
 	}
 
-	for _, letter := range cleartext {
+	reflectorName := *reflector
+	if reflectorName == "" {
+		reflectorName = "B"
+	}
 
-		if !unicode.IsLetter(letter) {
-			continue
+	var machine *enigma.Machine
+	var err error
+	if *keyString != "" {
+		var key *enigma.Key
+		if key, err = enigma.ParseKey(*keyString); err == nil {
+			opts := []enigma.Option{enigma.WithKey(key)}
+			if *modelName != "" {
+				opts = append(opts, enigma.WithModel(*modelName))
+			}
+			machine, err = enigma.New(opts...)
+		}
+	} else if *modelName != "" {
+		machine, err = enigma.BuildModel(*modelName, rotors...)
+	} else if rotors != nil {
+		machine, err = enigma.Build(reflectorName, rotors...)
+	} else if *greek != "" {
+		machine, err = enigma.BuildM4(reflectorName, *greek, *first, *second, *third)
+	} else {
+		machine, err = enigma.Build(reflectorName, *first, *second, *third)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *modelName != "" && *reflector != "" {
+		r := rotor.ChooseReflector(*reflector)
+		if r == nil {
+			log.Fatalf("no reflector %q\n", *reflector)
+		}
+		machine.SetReflector(r)
+	}
+	if *ukwd != "" {
+		r, err := rotor.NewReflectorD(strings.Split(*ukwd, ",")...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		machine.SetReflector(r)
+	}
+	if *stepping != "" {
+		model, ok := enigma.Steppings[*stepping]
+		if !ok {
+			log.Fatalf("unknown stepping model %q\n", *stepping)
 		}
+		machine.SetStepping(model)
+	}
+	if *entryWheel != "" {
+		if err := machine.UseEntryWheel(*entryWheel); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *reflectorSetting != "" {
+		if err := machine.TurnReflector(rune((*reflectorSetting)[0])); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-		letter = unicode.ToUpper(letter)
+	// A key string has already set the rings, rotors and plugs
+	if *keyString == "" {
+		if err := machine.SetRingSettings(*rings); err != nil {
+			log.Fatal(err)
+		}
+		if err := machine.SetPositions(*settings); err != nil {
+			log.Fatal(err)
+		}
 
-		if letter < 'A' || letter > 'Z' {
-			continue
+		if len(*plugs) > 0 {
+			swaps := strings.Split(*plugs, ",")
+			if *uhr >= 0 {
+				err = machine.SetUhr(*uhr, swaps...)
+			} else {
+				err = machine.SetPlugboard(swaps...)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
 		}
-		cipherLetter := machine.EncryptLetter(letter)
-		fmt.Printf("%c", cipherLetter)
 	}
-	fmt.Println()
+
+	machine.Advance(*skip)
+
+	out := bufio.NewWriter(os.Stdout)
+	cipherText := enigma.NewWriter(out, machine, mode)
+	if _, err := io.Copy(cipherText, input); err != nil {
+		log.Fatal(err)
+	}
+	if err := cipherText.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if mode != enigma.PassNonLetters {
+		fmt.Fprintln(out)
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,10 +1,10 @@
 package main
 
 import (
+	"enigmalike/stats"
 	"fmt"
 	"log"
 	"os"
-	"unicode"
 )
 
 func main() {
@@ -13,22 +13,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	N := 0
-	frequencies := make(map[rune]int)
-	for _, r := range string(buffer) {
-		if !unicode.IsLetter(r) {
-			continue
-		}
-		frequencies[unicode.ToUpper(r)]++
-		N++
-	}
-
-	sum := 0
-	for _, freq := range frequencies {
-		sum += freq * (freq - 1)
-	}
-
-	ic := float64(sum) / float64(N*(N-1))
+	N, ic := stats.UnicodeIndexOfCoincidence(string(buffer))
 
 	fmt.Printf("%d\t%0.5f\n", N, ic)
 }
@@ -1,12 +1,16 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Create a struct Rotor from in/out letter correspondence
 
 type RotorRep struct {
 	Name       string
 	OutLetters string
+	Notches    string
 }
 
 /*
@@ -17,35 +21,126 @@ II    = AJDKSIRUXBLHWTMCQGZNPYFVOE
 III   = BDFHJLCPRTXVZNYEIWGAKMUSQO
 IV    = ESOVPZJAYQUIRHXLNFTGKDCMWB
 V     = VZBRGITYUPSDNHLXAWMJQOFECK
+VI    = JPGVOUMFYQBENHZRDKASXLICTW
+VII   = NZJHGRCXMYSWBOUFAIVLPEKQDT
+VIII  = FKQHTLXOCBJSPDZRAMEWNIUYGV
+Beta  = LEYJVCNIXWPBQMDRTAKZGFUHOS
+Gamma = FSOKANUERHMBTIYCWLQPZXVGJD
+
+Commercial Enigma D and K, Swiss-K:
+K-I   = LPGSZMHAEOQKVXRFYBUTNICJDW
+K-II  = SLVGBTFXJQOHEWIRZYAMKPCNDU
+K-III = CJGDPSHKTURAWZXFMYNQOBVLIE
+SK-I  = PEZUOHXSCVFMTBGLRINQJWAYDK
+SK-II = ZOUESYDKFWPCIQXHMVBLGNJRAT
+SK-III= EHRVXGAOBQUSIMZFLYNWKTPDJC
+
+Abwehr Enigma G:
+G-I   = DMTWSILRUYQNKFEJCAZBPGXOHV
+G-II  = HQZGPJTMOBLNCIFDYAWVEUSRKX
+G-III = UQNTLSZFMREHDPXKIBVYGJCWOA
 */
 
 var rotors = []RotorRep{
 	RotorRep{
 		Name:       "I",
 		OutLetters: "EKMFLGDQVZNTOWYHXUSPAIBRCJ",
+		Notches:    "Q",
 	},
 	RotorRep{
 		Name:       "II",
 		OutLetters: "AJDKSIRUXBLHWTMCQGZNPYFVOE",
+		Notches:    "E",
 	},
 	RotorRep{
 		Name:       "III",
 		OutLetters: "BDFHJLCPRTXVZNYEIWGAKMUSQO",
+		Notches:    "V",
 	},
 	RotorRep{
 		Name:       "IV",
 		OutLetters: "ESOVPZJAYQUIRHXLNFTGKDCMWB",
+		Notches:    "J",
 	},
 	RotorRep{
 		Name:       "V",
 		OutLetters: "VZBRGITYUPSDNHLXAWMJQOFECK",
+		Notches:    "Z",
+	},
+	RotorRep{
+		Name:       "VI",
+		OutLetters: "JPGVOUMFYQBENHZRDKASXLICTW",
+		Notches:    "ZM",
+	},
+	RotorRep{
+		Name:       "VII",
+		OutLetters: "NZJHGRCXMYSWBOUFAIVLPEKQDT",
+		Notches:    "ZM",
+	},
+	RotorRep{
+		Name:       "VIII",
+		OutLetters: "FKQHTLXOCBJSPDZRAMEWNIUYGV",
+		Notches:    "ZM",
+	},
+	RotorRep{
+		Name:       "Beta",
+		OutLetters: "LEYJVCNIXWPBQMDRTAKZGFUHOS",
+	},
+	RotorRep{
+		Name:       "Gamma",
+		OutLetters: "FSOKANUERHMBTIYCWLQPZXVGJD",
+	},
+	RotorRep{
+		Name:       "K-I",
+		OutLetters: "LPGSZMHAEOQKVXRFYBUTNICJDW",
+		Notches:    "Y",
+	},
+	RotorRep{
+		Name:       "K-II",
+		OutLetters: "SLVGBTFXJQOHEWIRZYAMKPCNDU",
+		Notches:    "E",
+	},
+	RotorRep{
+		Name:       "K-III",
+		OutLetters: "CJGDPSHKTURAWZXFMYNQOBVLIE",
+		Notches:    "N",
+	},
+	RotorRep{
+		Name:       "SK-I",
+		OutLetters: "PEZUOHXSCVFMTBGLRINQJWAYDK",
+		Notches:    "Y",
+	},
+	RotorRep{
+		Name:       "SK-II",
+		OutLetters: "ZOUESYDKFWPCIQXHMVBLGNJRAT",
+		Notches:    "E",
+	},
+	RotorRep{
+		Name:       "SK-III",
+		OutLetters: "EHRVXGAOBQUSIMZFLYNWKTPDJC",
+		Notches:    "N",
+	},
+	RotorRep{
+		Name:       "G-I",
+		OutLetters: "DMTWSILRUYQNKFEJCAZBPGXOHV",
+		Notches:    "ABCEFGIKLOPQSUVWZ",
+	},
+	RotorRep{
+		Name:       "G-II",
+		OutLetters: "HQZGPJTMOBLNCIFDYAWVEUSRKX",
+		Notches:    "ACDFGHKMNQSTVYZ",
+	},
+	RotorRep{
+		Name:       "G-III",
+		OutLetters: "UQNTLSZFMREHDPXKIBVYGJCWOA",
+		Notches:    "AEFHKMNRUWX",
 	},
 }
 
 func main() {
 
 	for _, rotor := range rotors {
-		fmt.Printf("var Rotor%s = &Rotor{\n", rotor.Name)
+		fmt.Printf("var Rotor%s = &Rotor{\n", strings.ReplaceAll(rotor.Name, "-", ""))
 		fmt.Printf("\tEncode: [26]int{\n\t\t")
 		for i, letter := range rotor.OutLetters {
 			fmt.Printf("'%c' - 'A', ", letter)
@@ -68,6 +163,17 @@ func main() {
 		}
 		fmt.Println("\n\t},")
 
-		fmt.Println("\n}")
+		if len(rotor.Notches) > 0 {
+			fmt.Printf("\tNotches: []int{")
+			for i, letter := range rotor.Notches {
+				if i > 0 {
+					fmt.Print(", ")
+				}
+				fmt.Printf("'%c' - 'A'", letter)
+			}
+			fmt.Println("},")
+		}
+
+		fmt.Println("}")
 	}
 }
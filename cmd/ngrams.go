@@ -0,0 +1,77 @@
+package main
+
+/*
+ * Build n-gram tables from plaintext corpus files:
+ * ./ngrams -o german.ngrams corpus1.txt corpus2.txt ...
+ *
+ * or score text files with tables built before:
+ * ./ngrams -s german.ngrams decryption.txt ...
+ */
+
+import (
+	"enigmalike/stats"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	outFileName := flag.String("o", "", "n-gram tables file to write")
+	sizeList := flag.String("n", "1,2,3,4", "comma-separated n-gram sizes to count")
+	scoreFileName := flag.String("s", "", "n-gram tables file to score the files named on the command line with, instead of building tables")
+	flag.Parse()
+
+	if flag.NArg() == 0 || (*outFileName == "") == (*scoreFileName == "") {
+		log.Fatalf("usage: %s -o tables files... or %s -s tables files...\n", os.Args[0], os.Args[0])
+	}
+
+	if *scoreFileName != "" {
+		tables, err := stats.LoadTables(*scoreFileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range flag.Args() {
+			letters := readLetters(name)
+			fmt.Printf("%s\t%d\t%.05f", name, len(letters), stats.IndexOfCoincidence(letters))
+			for _, t := range tables {
+				fmt.Printf("\t%d:%.03f", t.N, t.PerLetter(letters))
+			}
+			fmt.Println()
+		}
+		return
+	}
+
+	var sizes []int
+	for _, size := range strings.Split(*sizeList, ",") {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			log.Fatalf("bad n-gram size %q\n", size)
+		}
+		sizes = append(sizes, n)
+	}
+
+	var corpus []rune
+	for _, name := range flag.Args() {
+		corpus = append(corpus, readLetters(name)...)
+	}
+
+	tables, err := stats.NewTables(corpus, sizes...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := stats.SaveTables(*outFileName, tables...); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d letters, IC %.05f\n", len(corpus), stats.IndexOfCoincidence(corpus))
+}
+
+func readLetters(name string) []rune {
+	buffer, err := os.ReadFile(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return stats.Letters(string(buffer))
+}
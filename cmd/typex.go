@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"enigmalike/enigma"
+	"enigmalike/typex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	inFileName := flag.String("i", "", "input file name")
+	rotorList := flag.String("r", "A,B,C,D,E", "comma-separated Typex rotors A-H, 2 stators first, then fast, middle, slow")
+	settings := flag.String("S", "AAAAA", "initial rotor settings")
+	rings := flag.String("R", "AAAAA", "rotor ring settings")
+	plugs := flag.String("P", "", "entry plugboard, the 26 contacts keys A through Z go to")
+	nonLetters := flag.String("n", "drop", "non-letters: drop, pass (unencrypted) or translit (Ä as AE, digits as words...)")
+	flag.Parse()
+
+	mode, ok := enigma.NonLetterModes[*nonLetters]
+	if !ok {
+		log.Fatalf("unknown non-letter handling %q\n", *nonLetters)
+	}
+
+	var input io.Reader = strings.NewReader("")
+	if *inFileName != "" {
+		fin, err := os.Open(*inFileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fin.Close()
+		input = fin
+	} else {
+		// no input file, read a string from the command line
+		if flag.NArg() > 0 {
+			input = strings.NewReader(flag.Arg(0))
+		}
+	}
+
+	machine, err := typex.Build(strings.Split(*rotorList, ",")...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := machine.Plugboard(*plugs); err != nil {
+		log.Fatal(err)
+	}
+	machine.SetRings(*rings)
+	machine.SetRotors(*settings)
+
+	out := bufio.NewWriter(os.Stdout)
+	cipherText := enigma.NewWriter(out, machine, mode)
+	if _, err := io.Copy(cipherText, input); err != nil {
+		log.Fatal(err)
+	}
+	if err := cipherText.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if mode != enigma.PassNonLetters {
+		fmt.Fprintln(out)
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,310 @@
+package cryptanalysis
+
+/*
+Weierud and Sullivan's attack starts where Gillogly's leaves off,
+with a rotor order and starting positions, and hill-climbs the
+ring settings and plugboard, first on a fitness that can see any
+improvement at all, the index of coincidence, then on n-gram scores
+that can tell good decryptions from nearly good ones.
+Hill-climbing gets stuck on local maxima, so it starts over from
+random plugboards and keeps the best it finds.
+*/
+
+import (
+	"enigmalike/enigma"
+	"fmt"
+	"math/rand"
+)
+
+// Climber hill-climbs ring settings and plugboards, with the stepping
+// model of the keys it's given. A Climber can be used by one goroutine
+// at a time, because of Rand.
+type Climber struct {
+	MaxPlugs int        // most plugboard cables, 10 if 0, at most enigma.MaxCables
+	Restarts int        // climbs from random plugboards, after one from the key's
+	Rand     *rand.Rand // for the random plugboards, nil for the global source
+}
+
+// Climb improves key's ring settings and plugboard for decrypting text,
+// with each of stages in turn, and returns the best key it found
+// and its score by the last stage, IC if there are no stages.
+// Key's rotor order, reflector and the wiring positions its rings
+// and starting positions give are kept, only the ring settings
+// of the fast and middle rotors change.
+func (c *Climber) Climb(key *enigma.Key, text []rune, stages ...Fitness) (*enigma.Key, float64, error) {
+	if err := c.check(); err != nil {
+		return nil, 0, err
+	}
+	d, err := newDecrypter(key, text)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(stages) == 0 {
+		stages = []Fitness{IC}
+	}
+
+	var best *enigma.Key
+	bestScore := 0.0
+	last := stages[len(stages)-1]
+
+	for restart := 0; restart <= c.Restarts; restart++ {
+		k := copyKey(key)
+		if restart > 0 {
+			k.Plugs = c.randomPlugs()
+		}
+
+		for stage, fitness := range stages {
+			if stage == 0 {
+				for rotor := 1; rotor <= 2 && rotor < len(k.Rotors); rotor++ {
+					if err := d.rings(k, rotor, fitness); err != nil {
+						return nil, 0, err
+					}
+				}
+			}
+			if err := d.plugboard(k, c.maxPlugs(), fitness); err != nil {
+				return nil, 0, err
+			}
+		}
+		// the middle rotor only steps every 26 letters or so,
+		// its ring shows up better with the plugs in
+		if len(k.Rotors) > 2 {
+			if err := d.rings(k, 2, last); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		score, err := d.score(k, last)
+		if err != nil {
+			return nil, 0, err
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = k, score
+		}
+	}
+
+	return best, bestScore, nil
+}
+
+// Rings climbs the ring setting of one rotor, 1 for the fast rotor,
+// keeping the rest of key, and returns key's score after
+func (c *Climber) Rings(key *enigma.Key, text []rune, rotor int, fitness Fitness) (float64, error) {
+	d, err := newDecrypter(key, text)
+	if err != nil {
+		return 0, err
+	}
+	if err := d.rings(key, rotor, fitness); err != nil {
+		return 0, err
+	}
+	return d.score(key, fitness)
+}
+
+// Plugboard climbs key's plugboard, keeping the rest of key,
+// and returns key's score after
+func (c *Climber) Plugboard(key *enigma.Key, text []rune, fitness Fitness) (float64, error) {
+	if err := c.check(); err != nil {
+		return 0, err
+	}
+	d, err := newDecrypter(key, text)
+	if err != nil {
+		return 0, err
+	}
+	if err := d.plugboard(key, c.maxPlugs(), fitness); err != nil {
+		return 0, err
+	}
+	return d.score(key, fitness)
+}
+
+// check returns an error for a MaxPlugs no plugboard takes
+func (c *Climber) check() error {
+	if c.MaxPlugs < 0 {
+		return fmt.Errorf("%w: %d", enigma.ErrTooFewCables, c.MaxPlugs)
+	}
+	if c.MaxPlugs > enigma.MaxCables {
+		return fmt.Errorf("%w: %d, a plugboard takes %d", enigma.ErrTooManyCables, c.MaxPlugs, enigma.MaxCables)
+	}
+	return nil
+}
+
+func (c *Climber) maxPlugs() int {
+	if c.MaxPlugs == 0 {
+		return 10
+	}
+	return c.MaxPlugs
+}
+
+// randomPlugs makes a plugboard of random pairs, as many as maxPlugs
+func (c *Climber) randomPlugs() []string {
+	perm := rand.Perm
+	if c.Rand != nil {
+		perm = c.Rand.Perm
+	}
+	letters := perm(26)
+	var plugs []string
+	for i := 0; i < c.maxPlugs(); i++ {
+		plugs = append(plugs, string([]rune{rune(letters[2*i] + 'A'), rune(letters[2*i+1] + 'A')}))
+	}
+	return plugs
+}
+
+func copyKey(key *enigma.Key) *enigma.Key {
+	k := *key
+	k.Rotors = append([]string(nil), key.Rotors...)
+	k.Plugs = append([]string(nil), key.Plugs...)
+	return &k
+}
+
+// decrypter decrypts one text with keys that differ only in
+// rings, positions and plugs, on one Machine
+type decrypter struct {
+	machine      *enigma.Machine
+	machineRings string // changing the ring setting drops Precompute's tables
+	text         []rune
+}
+
+func newDecrypter(key *enigma.Key, text []rune) (*decrypter, error) {
+	machine, err := enigma.New(enigma.WithKey(key))
+	if err != nil {
+		return nil, err
+	}
+	return &decrypter{machine: machine, machineRings: key.Rings, text: text}, nil
+}
+
+func (d *decrypter) decrypt(key *enigma.Key) ([]rune, error) {
+	if key.Rings != d.machineRings {
+		if err := d.machine.SetRingSettings(key.Rings); err != nil {
+			return nil, err
+		}
+		d.machineRings = key.Rings
+	}
+	if err := d.machine.SetPositions(key.Positions); err != nil {
+		return nil, err
+	}
+	if err := d.machine.SetPlugboard(key.Plugs...); err != nil {
+		return nil, err
+	}
+	return d.machine.EncryptBuffer(d.text), nil
+}
+
+func (d *decrypter) score(key *enigma.Key, fitness Fitness) (float64, error) {
+	plaintext, err := d.decrypt(key)
+	if err != nil {
+		return 0, err
+	}
+	return fitness.Score(plaintext), nil
+}
+
+// rings finds the ring setting of rotor number rotor, 1 for the fast rotor,
+// with the best fitness. Turning a ring and the rotor's position
+// the same amount leaves the wiring where it was, and only
+// moves when the rotor steps the next one. Moving that can leave
+// the next rotor a letter out, so try it either side too.
+func (d *decrypter) rings(key *enigma.Key, rotor int, fitness Fitness) error {
+	i := rotor - 1
+	rings, positions := key.Rings, key.Positions
+	bestRings, bestPositions := rings, positions
+	best, err := d.score(key, fitness)
+	if err != nil {
+		return err
+	}
+
+	nudges := []byte{0}
+	if rotor < len(positions) {
+		nudges = append(nudges, 1, 25)
+	}
+	for shift := byte(0); shift < 26; shift++ {
+		for _, nudge := range nudges {
+			if shift == 0 && nudge == 0 {
+				continue
+			}
+			trialRings, trialPositions := []byte(rings), []byte(positions)
+			trialRings[i] = (rings[i]-'A'+shift)%26 + 'A'
+			trialPositions[i] = (positions[i]-'A'+shift)%26 + 'A'
+			if nudge != 0 {
+				trialPositions[i+1] = (positions[i+1]-'A'+nudge)%26 + 'A'
+			}
+			key.Rings, key.Positions = string(trialRings), string(trialPositions)
+			score, err := d.score(key, fitness)
+			if err != nil {
+				return err
+			}
+			if score > best {
+				best, bestRings, bestPositions = score, key.Rings, key.Positions
+			}
+		}
+	}
+	key.Rings, key.Positions = bestRings, bestPositions
+	return nil
+}
+
+// plugboard climbs to a better plugboard. For each pair of letters
+// it tries cabling them together, pulling their cables out first,
+// and if both had cables, cabling their old partners together too,
+// or just pulling out the cable between them. It keeps any change
+// that improves fitness, and goes round again until none does.
+func (d *decrypter) plugboard(key *enigma.Key, maxPlugs int, fitness Fitness) error {
+	// the rings stay put while the plugs change, so one table covers the positions
+	if _, err := d.decrypt(key); err != nil {
+		return err
+	}
+	// more than 4 rotors have no tables, and are just slower
+	_ = d.machine.Precompute()
+
+	var partner [26]int
+	for i := range partner {
+		partner[i] = i
+	}
+	for _, plug := range key.Plugs {
+		a, b := int(plug[0]-'A'), int(plug[1]-'A')
+		partner[a], partner[b] = b, a
+	}
+
+	best, err := d.score(key, fitness)
+	if err != nil {
+		return err
+	}
+	for improved := true; improved; {
+		improved = false
+		for a := 0; a < 26; a++ {
+			for b := a + 1; b < 26; b++ {
+				trial := partner
+				if trial[a] == b {
+					trial[a], trial[b] = a, b
+				} else {
+					x, y := trial[a], trial[b]
+					trial[x], trial[y] = x, y
+					trial[a], trial[b] = b, a
+					if x != a && y != b {
+						trial[x], trial[y] = y, x
+					}
+				}
+				plugs := partnerPlugs(trial)
+				if len(plugs) > maxPlugs {
+					continue
+				}
+
+				key.Plugs = plugs
+				score, err := d.score(key, fitness)
+				if err != nil {
+					return err
+				}
+				if score > best {
+					best, partner, improved = score, trial, true
+				}
+			}
+		}
+	}
+	key.Plugs = partnerPlugs(partner)
+	return nil
+}
+
+// partnerPlugs lists the cables of a plugboard where each letter's
+// partner is the letter it's cabled to, or itself
+func partnerPlugs(partner [26]int) []string {
+	var plugs []string
+	for a, b := range partner {
+		if a < b {
+			plugs = append(plugs, string([]rune{rune(a + 'A'), rune(b + 'A')}))
+		}
+	}
+	return plugs
+}
@@ -0,0 +1,26 @@
+package cryptanalysis
+
+import (
+	"enigmalike/enigma"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestClimbMaxPlugs(t *testing.T) {
+	key, err := enigma.ParseKey("B I-II-III 01-01-01 ADU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := []rune("QMJIDOMZWZJFJRBQPYXNLTXDSAGWCLDYMFUNIXDCQRA")
+
+	c := &Climber{MaxPlugs: enigma.MaxCables + 1, Restarts: 1, Rand: rand.New(rand.NewSource(1))}
+	if _, _, err := c.Climb(key, text); !errors.Is(err, enigma.ErrTooManyCables) {
+		t.Errorf("MaxPlugs %d: error %v", c.MaxPlugs, err)
+	}
+
+	c.MaxPlugs = enigma.MaxCables
+	if _, _, err := c.Climb(key, text); err != nil {
+		t.Errorf("MaxPlugs %d: %v", c.MaxPlugs, err)
+	}
+}
@@ -0,0 +1,22 @@
+package cryptanalysis
+
+import "enigmalike/stats"
+
+// Fitness scores a decryption, the higher the more like plaintext.
+// Text is uppercase letters 'A' through 'Z'.
+// A *stats.Table is a Fitness, scoring by its n-grams.
+type Fitness interface {
+	Score(text []rune) float64
+}
+
+// FitnessFunc lets an ordinary function be a Fitness
+type FitnessFunc func(text []rune) float64
+
+func (f FitnessFunc) Score(text []rune) float64 {
+	return f(text)
+}
+
+// IC is the index of coincidence as a Fitness. It needs no
+// knowledge of the plaintext language, only that it isn't random,
+// but can't tell a right decryption from a nearly right one.
+var IC = FitnessFunc(stats.IndexOfCoincidence)
@@ -0,0 +1,141 @@
+package enigma
+
+import (
+	"encoding/json"
+	"enigmalike/rotor"
+	"fmt"
+	"io"
+	"os"
+)
+
+/*
+A definitions file for LoadDefinitions is a rotor package definitions
+file that can also define models, using the new wirings or the built-in ones:
+
+	{
+		"rotors": [
+			{"name": "IX", "wiring": "KPTYUELOCVGRFQDANJMBSWHZXI", "notches": "HN"}
+		],
+		"models": [
+			{"name": "I-IX", "reflector": "B", "entry_wheel": "ABC",
+			 "rotors": ["IX", "II", "I"], "stepping": "double"}
+		]
+	}
+
+Model rotors are fast rotor first, stepping is a name from Steppings,
+double if it's left out.
+*/
+
+// Definitions is the contents of a definitions file
+type Definitions struct {
+	rotor.Definitions
+	Models []ModelDefinition `json:"models"`
+}
+
+// ModelDefinition is a Model in a definitions file
+type ModelDefinition struct {
+	Name       string   `json:"name"`
+	Reflector  string   `json:"reflector"`
+	EntryWheel string   `json:"entry_wheel"`
+	Rotors     []string `json:"rotors"`
+	Stationary int      `json:"stationary"`
+	Stepping   string   `json:"stepping"`
+}
+
+// LoadDefinitionsFile reads a definitions file by name, see LoadDefinitions
+func LoadDefinitionsFile(name string) error {
+	fin, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer fin.Close()
+
+	return LoadDefinitions(fin)
+}
+
+// LoadDefinitions reads a definitions file, checks every wiring and model,
+// and adds them to rotor.Rotors, rotor.Reflectors, rotor.EntryWheels and Models.
+// If anything is wrong it adds nothing.
+func LoadDefinitions(r io.Reader) error {
+	var defs Definitions
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&defs); err != nil {
+		return fmt.Errorf("definitions: %w", err)
+	}
+
+	models := make(map[string]*Model)
+	for _, def := range defs.Models {
+		model, err := defs.model(def)
+		if err != nil {
+			return fmt.Errorf("model %q: %w", def.Name, err)
+		}
+		if Models[def.Name] != nil || models[def.Name] != nil {
+			return fmt.Errorf("model %q already defined", def.Name)
+		}
+		models[def.Name] = model
+	}
+
+	if err := defs.Register(); err != nil {
+		return err
+	}
+	for name, model := range models {
+		Models[name] = model
+	}
+
+	return nil
+}
+
+// model checks that def only uses parts that are built in or in defs
+func (defs *Definitions) model(def ModelDefinition) (*Model, error) {
+	if def.Name == "" {
+		return nil, fmt.Errorf("model without a name")
+	}
+
+	stepping, ok := DoubleStepping, true
+	if def.Stepping != "" {
+		stepping, ok = Steppings[def.Stepping]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no stepping model %q", def.Stepping)
+	}
+	if len(def.Rotors) == 0 {
+		return nil, fmt.Errorf("no rotors")
+	}
+	if def.Stationary < 0 || def.Stationary >= len(def.Rotors) {
+		return nil, fmt.Errorf("can't make %d of %d rotors stationary", def.Stationary, len(def.Rotors))
+	}
+
+	for _, name := range def.Rotors {
+		if rotor.Rotors[name] == nil && !defined(defs.Rotors, name) {
+			return nil, fmt.Errorf("no rotor %q", name)
+		}
+	}
+	if rotor.Reflectors[def.Reflector] == nil && !defined(defs.Reflectors, def.Reflector) {
+		return nil, fmt.Errorf("no reflector %q", def.Reflector)
+	}
+	entryWheel := def.EntryWheel
+	if entryWheel == "" {
+		entryWheel = "ABC"
+	}
+	if rotor.EntryWheels[entryWheel] == nil && !defined(defs.EntryWheels, entryWheel) {
+		return nil, fmt.Errorf("no entry wheel %q", entryWheel)
+	}
+
+	return &Model{
+		Reflector:  def.Reflector,
+		EntryWheel: entryWheel,
+		Rotors:     def.Rotors,
+		Stationary: def.Stationary,
+		Stepping:   stepping,
+	}, nil
+}
+
+func defined(defs []rotor.Definition, name string) bool {
+	for _, def := range defs {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
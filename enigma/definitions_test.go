@@ -0,0 +1,91 @@
+package enigma
+
+import (
+	"enigmalike/rotor"
+	"strings"
+	"testing"
+)
+
+// forget removes whatever a test's definitions may have registered
+func forget(t *testing.T) {
+	t.Cleanup(func() {
+		delete(rotor.Rotors, "Test-IX")
+		delete(rotor.Reflectors, "Test-X")
+		delete(rotor.EntryWheels, "Test-AZERTY")
+		delete(Models, "Test-Model")
+	})
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	forget(t)
+	err := LoadDefinitions(strings.NewReader(`{
+		"rotors": [{"name": "Test-IX", "wiring": "KPTYUELOCVGRFQDANJMBSWHZXI", "notches": "HN"}],
+		"reflectors": [{"name": "Test-X", "wiring": "YRUHQSLDPXNGOKMIEBFZCWVJAT"}],
+		"entry_wheels": [{"name": "Test-AZERTY", "wiring": "AZERTYUIOPQSDFGHJKLMWXCVBN"}],
+		"models": [{"name": "Test-Model", "reflector": "Test-X", "entry_wheel": "Test-AZERTY",
+			"rotors": ["Test-IX", "II", "I"], "stepping": "double"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rotor.Rotors["Test-IX"] == nil || rotor.Reflectors["Test-X"] == nil || rotor.EntryWheels["Test-AZERTY"] == nil {
+		t.Fatal("wirings not registered")
+	}
+	m, err := BuildModel("Test-Model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.stepping != DoubleStepping {
+		t.Errorf("model has %v stepping, want double", m.stepping)
+	}
+	plaintext := []rune("HELLOWORLD")
+	ciphertext := m.EncryptBuffer(plaintext)
+	m, err = BuildModel("Test-Model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m.EncryptBuffer(ciphertext)); got != string(plaintext) {
+		t.Errorf("%s decrypts to %s, want %s", string(ciphertext), got, string(plaintext))
+	}
+}
+
+func TestLoadDefinitionsRejects(t *testing.T) {
+	good := map[string]string{
+		"rotors":       `[{"name": "Test-IX", "wiring": "KPTYUELOCVGRFQDANJMBSWHZXI", "notches": "HN"}]`,
+		"reflectors":   `[{"name": "Test-X", "wiring": "YRUHQSLDPXNGOKMIEBFZCWVJAT"}]`,
+		"entry_wheels": `[{"name": "Test-AZERTY", "wiring": "AZERTYUIOPQSDFGHJKLMWXCVBN"}]`,
+		"models":       `[{"name": "Test-Model", "reflector": "Test-X", "rotors": ["Test-IX", "II", "I"], "stepping": "double"}]`,
+	}
+	tests := []struct {
+		name, section, bad string
+	}{
+		{"repeated rotor letter", "rotors",
+			`[{"name": "Test-IX", "wiring": "KKTYUELOCVGRFQDANJMBSWHZXI"}]`},
+		{"reflector that doesn't pair", "reflectors",
+			`[{"name": "Test-X", "wiring": "BCDEFGHIJKLMNOPQRSTUVWXYZA"}]`},
+		{"unknown rotor in model", "models",
+			`[{"name": "Test-Model", "reflector": "Test-X", "rotors": ["Test-IX", "Nope"], "stepping": "double"}]`},
+		{"unknown reflector in model", "models",
+			`[{"name": "Test-Model", "reflector": "Nope", "rotors": ["Test-IX"], "stepping": "double"}]`},
+		{"unknown stepping in model", "models",
+			`[{"name": "Test-Model", "reflector": "Test-X", "rotors": ["Test-IX"], "stepping": "nope"}]`},
+	}
+	forget(t)
+	for _, tt := range tests {
+		var sections []string
+		for section, definitions := range good {
+			if section == tt.section {
+				definitions = tt.bad
+			}
+			sections = append(sections, `"`+section+`": `+definitions)
+		}
+		if err := LoadDefinitions(strings.NewReader("{" + strings.Join(sections, ",") + "}")); err == nil {
+			t.Errorf("%s: loaded", tt.name)
+		}
+		if rotor.Rotors["Test-IX"] != nil || rotor.Reflectors["Test-X"] != nil ||
+			rotor.EntryWheels["Test-AZERTY"] != nil || Models["Test-Model"] != nil {
+			t.Errorf("%s: registered some definitions", tt.name)
+		}
+	}
+}
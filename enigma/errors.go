@@ -0,0 +1,69 @@
+package enigma
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors the error-returning constructors and setters wrap,
+// so callers can check for them with errors.Is
+var (
+	ErrNoRotors         = errors.New("no rotors")
+	ErrUnknownRotor     = errors.New("unknown rotor")
+	ErrDuplicateRotor   = errors.New("rotor used twice")
+	ErrUnknownReflector = errors.New("unknown reflector")
+	ErrUnknownModel     = errors.New("unknown Enigma model")
+	ErrWrongPart        = errors.New("part doesn't fit this machine")
+	ErrInvalidPlug      = errors.New("invalid plug")
+	ErrLetterUsedTwice  = errors.New("letter used twice")
+	ErrTooManyCables    = errors.New("too many cables")
+	ErrTooFewCables     = errors.New("too few cables")
+	ErrBadSetting       = errors.New("bad setting")
+	ErrTooManySettings  = errors.New("more settings than rotors")
+	ErrTooManyStates    = errors.New("too many rotor positions to precompute")
+)
+
+// MaxCables is how many Stecker cables a plugboard has sockets for
+const MaxCables = 13
+
+// RotorError says which rotor Build or BuildModel couldn't use.
+// Err is ErrUnknownRotor, ErrDuplicateRotor or ErrWrongPart.
+type RotorError struct {
+	Position int // 1 for the fast rotor
+	Name     string
+	Err      error
+}
+
+func (e *RotorError) Error() string {
+	return fmt.Sprintf("rotor %d %q: %v", e.Position, e.Name, e.Err)
+}
+
+func (e *RotorError) Unwrap() error { return e.Err }
+
+// PlugError says which plugboard or Uhr pair SetPlugboard or SetUhr
+// couldn't use. Err is ErrInvalidPlug or ErrLetterUsedTwice.
+type PlugError struct {
+	Plug string
+	Err  error
+}
+
+func (e *PlugError) Error() string {
+	return fmt.Sprintf("plug %q: %v", e.Plug, e.Err)
+}
+
+func (e *PlugError) Unwrap() error { return e.Err }
+
+// SettingError says which letter of a rotor or ring setting
+// SetPositions or SetRingSettings couldn't use.
+// Err is ErrBadSetting or ErrTooManySettings.
+type SettingError struct {
+	Setting  string
+	Position int // 1 for the fast rotor
+	Err      error
+}
+
+func (e *SettingError) Error() string {
+	return fmt.Sprintf("setting %q letter %d: %v", e.Setting, e.Position, e.Err)
+}
+
+func (e *SettingError) Unwrap() error { return e.Err }
@@ -0,0 +1,197 @@
+package enigma
+
+import (
+	"enigmalike/rotor"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrBadKey is wrapped by the errors ParseKey returns for
+// a key string that doesn't have the right fields
+var ErrBadKey = errors.New("bad key")
+
+// Key is everything a key sheet line says about setting up a Machine.
+// Rotors, Rings and Positions are fast rotor first, like the rest
+// of this package, while the key string lists them the way
+// a key sheet does, slow rotor first:
+//
+//	B I-II-III 01-01-01 ABC AB CD EF
+//
+// is reflector B, rotor I next to the reflector and rotor III next
+// to the plugboard, all rings at 01 ('A'), rotor I turned to A,
+// rotor III to C, and Stecker cables AB, CD and EF.
+//
+// Real Enigmas double step, so a key string means DoubleStepping
+// unless it starts with the name of another stepping model in Steppings,
+// like "notch B I-II-III 01-01-01 ABC". A Greek rotor, Beta or Gamma,
+// goes next to the reflector, which has to be a thin one, and never steps.
+type Key struct {
+	Stepping  Stepping
+	Reflector string
+	Rotors    []string
+	Rings     string // a letter for each rotor, 'A' is ring setting 01
+	Positions string // a letter for each rotor
+	Plugs     []string
+}
+
+// ParseKey reads a key string like the one in Key's comment.
+// Rotor names can have a '-' in them, like "K-I", so the rotor field
+// splits into the longest names rotor.Rotors knows, from the left.
+// Ring settings are 01 through 26. The key has to build a Machine,
+// so unknown rotors or bad plugs are errors here, as in New.
+func ParseKey(s string) (*Key, error) {
+	fields := strings.Fields(s)
+	k := &Key{Stepping: DoubleStepping}
+	if len(fields) > 0 {
+		if stepping, ok := Steppings[fields[0]]; ok {
+			k.Stepping = stepping
+			fields = fields[1:]
+		}
+	}
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("%w %q: needs reflector, rotors, rings and positions", ErrBadKey, s)
+	}
+
+	k.Reflector = fields[0]
+
+	rotors, err := splitRotors(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	rings := strings.Split(fields[2], "-")
+	positions := []rune(strings.ToUpper(fields[3]))
+	if len(rings) != len(rotors) || len(positions) != len(rotors) {
+		return nil, fmt.Errorf("%w %q: %d rotors, %d rings, %d positions",
+			ErrBadKey, s, len(rotors), len(rings), len(positions))
+	}
+
+	// Key sheet order is slow rotor first, turn it around
+	var ringLetters, positionLetters []rune
+	for i := len(rotors) - 1; i >= 0; i-- {
+		ring, err := strconv.Atoi(rings[i])
+		if err != nil || ring < 1 || ring > 26 {
+			return nil, &SettingError{Setting: fields[2], Position: i + 1, Err: ErrBadSetting}
+		}
+		if !unicode.IsUpper(positions[i]) || positions[i] > 'Z' {
+			return nil, &SettingError{Setting: fields[3], Position: i + 1, Err: ErrBadSetting}
+		}
+		k.Rotors = append(k.Rotors, rotors[i])
+		ringLetters = append(ringLetters, rune(ring-1+'A'))
+		positionLetters = append(positionLetters, positions[i])
+	}
+	k.Rings = string(ringLetters)
+	k.Positions = string(positionLetters)
+
+	for _, plug := range fields[4:] {
+		k.Plugs = append(k.Plugs, strings.ToUpper(plug))
+	}
+
+	if err := k.checkGreek(); err != nil {
+		return nil, err
+	}
+
+	if _, err := New(WithKey(k)); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// GreekRotors are the thin rotors of the M4, that only fit
+// next to a thin reflector, and never step
+var GreekRotors = map[string]bool{"Beta": true, "Gamma": true}
+
+// ThinReflectors are the reflectors that leave room for a Greek rotor
+var ThinReflectors = map[string]bool{"B-thin": true, "C-thin": true}
+
+// greek counts the target Key's stationary rotors, 1 if a Greek rotor
+// is next to the reflector
+func (k *Key) greek() int {
+	if len(k.Rotors) > 0 && GreekRotors[k.Rotors[len(k.Rotors)-1]] {
+		return 1
+	}
+	return 0
+}
+
+// checkGreek makes sure a Greek rotor is next to the reflector,
+// and there's one exactly when the reflector is thin
+func (k *Key) checkGreek() error {
+	for i, name := range k.Rotors {
+		if GreekRotors[name] && i != len(k.Rotors)-1 {
+			return &RotorError{Position: i + 1, Name: name, Err: ErrWrongPart}
+		}
+	}
+	if (k.greek() == 1) != ThinReflectors[k.Reflector] {
+		return fmt.Errorf("%w: reflector %s with rotors %s, a Greek rotor needs a thin reflector and the other way round",
+			ErrWrongPart, k.Reflector, strings.Join(k.Rotors, ","))
+	}
+	return nil
+}
+
+// splitRotors breaks a key's rotor field like "Beta-K-III-II" into
+// rotor names, taking the longest known name at each point
+func splitRotors(field string) ([]string, error) {
+	parts := strings.Split(field, "-")
+	var names []string
+	for len(parts) > 0 {
+		n := len(parts)
+		for ; n > 0; n-- {
+			if _, ok := rotor.Rotors[strings.Join(parts[:n], "-")]; ok {
+				break
+			}
+		}
+		if n == 0 {
+			return nil, &RotorError{Position: len(names) + 1, Name: parts[0], Err: ErrUnknownRotor}
+		}
+		names = append(names, strings.Join(parts[:n], "-"))
+		parts = parts[n:]
+	}
+	return names, nil
+}
+
+// String formats the target Key the way ParseKey reads it,
+// slow rotor first, with the plugs sorted, and the stepping model
+// unless it's DoubleStepping, so equal keys have equal strings.
+func (k *Key) String() string {
+	var rotors, rings []string
+	var positions []byte
+	for i := len(k.Rotors) - 1; i >= 0; i-- {
+		rotors = append(rotors, k.Rotors[i])
+		ring, position := byte('A'), byte('A')
+		if i < len(k.Rings) {
+			ring = k.Rings[i]
+		}
+		if i < len(k.Positions) {
+			position = k.Positions[i]
+		}
+		rings = append(rings, fmt.Sprintf("%02d", ring-'A'+1))
+		positions = append(positions, position)
+	}
+
+	var fields []string
+	if k.Stepping != DoubleStepping {
+		fields = append(fields, k.Stepping.String())
+	}
+	fields = append(fields, k.Reflector, strings.Join(rotors, "-"), strings.Join(rings, "-"), string(positions))
+	fields = append(fields, sortedPlugs(k.Plugs)...)
+
+	return strings.Join(fields, " ")
+}
+
+// sortedPlugs puts each pair's letters in order, then the pairs,
+// since "BA" is the same cable as "AB"
+func sortedPlugs(plugs []string) []string {
+	var sorted []string
+	for _, plug := range plugs {
+		letters := []rune(strings.ToUpper(plug))
+		sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+		sorted = append(sorted, string(letters))
+	}
+	sort.Strings(sorted)
+	return sorted
+}
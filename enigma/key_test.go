@@ -0,0 +1,91 @@
+package enigma
+
+import "testing"
+
+func TestKeyRoundTrip(t *testing.T) {
+	tests := []struct {
+		key      string
+		stepping Stepping
+	}{
+		{"B I-II-III 01-01-01 ADU", DoubleStepping},
+		{"notch B I-II-III 01-02-03 ADU AB CD", NotchStepping},
+		{"C-thin Gamma-VIII-VI-V 26-01-13-02 QEVZ AZ BY", DoubleStepping},
+	}
+	for _, tt := range tests {
+		k, err := ParseKey(tt.key)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.key, err)
+		}
+		if k.Stepping != tt.stepping {
+			t.Errorf("%s: stepping %v, want %v", tt.key, k.Stepping, tt.stepping)
+		}
+		if got := k.String(); got != tt.key {
+			t.Errorf("%s comes back as %s", tt.key, got)
+		}
+	}
+}
+
+// A key encrypts the same with or without the model it's for
+func TestKeyWithModel(t *testing.T) {
+	tests := []struct{ key, model string }{
+		{"B I-II-III 01-01-01 ADU", "I"},
+		{"B-thin Beta-II-IV-I 01-01-01-01 AAAA", "M4"},
+	}
+	plaintext := make([]rune, 20000)
+	for i := range plaintext {
+		plaintext[i] = 'A'
+	}
+	for _, tt := range tests {
+		k, err := ParseKey(tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bare, err := New(WithKey(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		model, err := New(WithKey(k), WithModel(tt.model))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bare.EncryptBuffer(plaintext)) != string(model.EncryptBuffer(plaintext)) {
+			t.Errorf("%s encrypts differently with model %s", tt.key, tt.model)
+		}
+	}
+}
+
+func TestKeyGreekRotor(t *testing.T) {
+	for _, key := range []string{
+		"B Beta-II-IV-I 01-01-01-01 AAAA",
+		"B-thin II-IV-I 01-01-01 AAA",
+		"B-thin II-Beta-IV-I 01-01-01-01 AAAA",
+	} {
+		if _, err := ParseKey(key); err == nil {
+			t.Errorf("%s: no error", key)
+		}
+	}
+}
+
+// A key encrypts the same as the settings it stands for,
+// given one at a time with the default stepping
+func TestKeyWithoutKey(t *testing.T) {
+	k, err := ParseKey("B I-II-III 01-01-01 ADU AB CD EF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyed, err := New(WithKey(k))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := New(WithRotors("III", "II", "I"), WithPositions("UDA"), WithPlugs("AB", "CD", "EF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]rune, 2000)
+	for i := range plaintext {
+		plaintext[i] = 'A'
+	}
+	if string(keyed.EncryptBuffer(plaintext)) != string(settings.EncryptBuffer(plaintext)) {
+		t.Error("key encrypts differently from its settings")
+	}
+}
@@ -2,50 +2,234 @@ package enigma
 
 import (
 	"enigmalike/rotor"
+	"fmt"
 	"log"
 	"unicode"
 )
 
 type Machine struct {
-	rotor1    *rotor.Rotor
-	rotor2    *rotor.Rotor
-	rotor3    *rotor.Rotor
-	reflector *rotor.Reflector
-	plugBoard [26]int
+	// rotors[0] is the fast rotor, next to the plugboard,
+	// the last rotor is next to the reflector
+	rotors     []*rotor.Rotor
+	stationary int // how many rotors next to the reflector never step
+	reflector  *rotor.Reflector
+	entry      *rotor.EntryWheel
+	plugBoard  [26]int // key to rotors
+	plugReturn [26]int // rotors to lamp, the same as plugBoard except with the Uhr
+	stepping   Stepping
+	tables     *scramblerTables // nil unless Precompute made some
 }
 
-// NewMachine arranges 3 rotors ("first" is leftmost), but doesn't set them
-func NewMachine(first, second, third string) *Machine {
-	// set up rotors
-	var rotor1, rotor2, rotor3 *rotor.Rotor
+// Stepping selects how a keypress advances a Machine's rotors
+type Stepping int
 
-	if rotor1 = rotor.ChooseRotor(first); rotor1 == nil {
-		log.Printf("no first rotor %q\n", first)
-		return nil
+const (
+	// NotchStepping steps the next rotor left when a rotor
+	// steps off one of its notches.
+	NotchStepping Stepping = iota
+	// LegacyStepping steps the next rotor left when a rotor
+	// wraps around to 'A', every 26 steps, whatever rotor it is.
+	LegacyStepping
+	// DoubleStepping emulates the pawls of a real Enigma, including
+	// the middle rotor stepping on two consecutive keypresses.
+	DoubleStepping
+	// CogStepping is the gear drive of the Abwehr Enigma G, stepping
+	// like NotchStepping, without a double step, except that
+	// the last stepping rotor's carry also steps the reflector.
+	CogStepping
+)
+
+// Steppings maps command line names to stepping models
+var Steppings = map[string]Stepping{
+	"notch":  NotchStepping,
+	"legacy": LegacyStepping,
+	"double": DoubleStepping,
+	"cog":    CogStepping,
+}
+
+// String returns the stepping model's name in Steppings
+func (s Stepping) String() string {
+	for name, stepping := range Steppings {
+		if stepping == s {
+			return name
+		}
 	}
-	if rotor2 = rotor.ChooseRotor(second); rotor2 == nil {
-		log.Printf("no second rotor %q\n", second)
+	return fmt.Sprintf("Stepping(%d)", int(s))
+}
+
+// NewMachine arranges 3 rotors ("first" is leftmost), but doesn't set them.
+// It uses reflector B.
+func NewMachine(first, second, third string) *Machine {
+	return NewMachineWithReflector("B", first, second, third)
+}
+
+// NewMachineWithReflector arranges 3 rotors like NewMachine,
+// with the reflector named by the first argument, one of rotor.Reflectors
+func NewMachineWithReflector(reflector, first, second, third string) *Machine {
+	return NewRotorStack(reflector, first, second, third)
+}
+
+// NewRotorStack arranges any number of rotors, fast rotor first,
+// between the plugboard and the named reflector, but doesn't set them.
+// All of the rotors step until SetStationary says otherwise.
+// It logs the problem and returns nil where Build returns an error.
+func NewRotorStack(reflector string, names ...string) *Machine {
+	m, err := Build(reflector, names...)
+	if err != nil {
+		log.Print(err)
 		return nil
 	}
-	if rotor3 = rotor.ChooseRotor(third); rotor3 == nil {
-		log.Printf("no third rotor %q\n", third)
-		return nil
+	return m
+}
+
+// Build arranges rotors like NewRotorStack, returning an error for
+// a missing, unknown or repeated rotor, or an unknown reflector.
+func Build(reflector string, names ...string) (*Machine, error) {
+	if len(names) == 0 {
+		return nil, ErrNoRotors
 	}
 
-	m := &Machine{
-		rotor1:    rotor1,
-		rotor2:    rotor2,
-		rotor3:    rotor3,
-		reflector: rotor.ReflectorB,
+	m := &Machine{stepping: DoubleStepping}
+
+	for i, name := range names {
+		for j := 0; j < i; j++ {
+			if names[j] == name {
+				return nil, &RotorError{Position: i + 1, Name: name, Err: ErrDuplicateRotor}
+			}
+		}
+		r := rotor.ChooseRotor(name)
+		if r == nil {
+			return nil, &RotorError{Position: i + 1, Name: name, Err: ErrUnknownRotor}
+		}
+		m.rotors = append(m.rotors, r)
+	}
+	if m.reflector = rotor.ChooseReflector(reflector); m.reflector == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownReflector, reflector)
 	}
+	m.entry = rotor.EntryWheelABC
 
 	for i := range m.plugBoard {
 		m.plugBoard[i] = i
+		m.plugReturn[i] = i
+	}
+
+	return m, nil
+}
+
+// SetStepping chooses the stepping model of the target enigma.Machine.
+// A new Machine uses DoubleStepping, like a real Enigma.
+func (m *Machine) SetStepping(stepping Stepping) {
+	m.stepping = stepping
+	m.tables = nil
+	for _, r := range m.rotors {
+		r.WrapCarry = stepping == LegacyStepping
+	}
+}
+
+// SetReflector replaces the target enigma.Machine's reflector,
+// with one from rotor.NewReflector or rotor.NewReflectorD, say.
+func (m *Machine) SetReflector(reflector *rotor.Reflector) {
+	m.reflector = reflector
+	m.tables = nil
+}
+
+// SetReflectorPosition turns the target enigma.Machine's reflector to
+// a letter, [A-Z]. Only the commercial machines could do that,
+// the military reflectors stay at 'A'.
+// It logs the problem where TurnReflector returns an error.
+func (m *Machine) SetReflectorPosition(setting rune) {
+	if err := m.TurnReflector(setting); err != nil {
+		log.Print(err)
 	}
+}
+
+// TurnReflector turns the reflector like SetReflectorPosition,
+// returning a SettingError for a setting that isn't a letter
+func (m *Machine) TurnReflector(setting rune) error {
+	setting = unicode.ToUpper(setting)
+	if setting < 'A' || setting > 'Z' {
+		return &SettingError{Setting: string(setting), Position: 1, Err: ErrBadSetting}
+	}
+	m.reflector.Steps = int(setting - 'A')
+	m.tables = nil
+	return nil
+}
+
+// SetEntryWheel replaces the target enigma.Machine's entry wheel
+// with one named in rotor.EntryWheels. A new Machine has the
+// military "ABC" entry wheel.
+// It logs the problem where UseEntryWheel returns an error.
+func (m *Machine) SetEntryWheel(name string) {
+	if err := m.UseEntryWheel(name); err != nil {
+		log.Print(err)
+	}
+}
+
+// UseEntryWheel replaces the entry wheel like SetEntryWheel,
+// returning an error wrapping ErrWrongPart for an unknown one
+func (m *Machine) UseEntryWheel(name string) error {
+	entry := rotor.ChooseEntryWheel(name)
+	if entry == nil {
+		return fmt.Errorf("%w: no entry wheel %q", ErrWrongPart, name)
+	}
+	m.entry = entry
+	m.tables = nil
+	return nil
+}
+
+// SetStationary keeps the last n rotors of the target enigma.Machine,
+// the ones next to the reflector, from ever stepping.
+// The Greek rotor of an M4 is stationary.
+// It logs the problem where KeepStationary returns an error.
+func (m *Machine) SetStationary(n int) {
+	if err := m.KeepStationary(n); err != nil {
+		log.Print(err)
+	}
+}
+
+// KeepStationary keeps rotors from stepping like SetStationary,
+// returning an error wrapping ErrWrongPart unless at least
+// one rotor still steps
+func (m *Machine) KeepStationary(n int) error {
+	if n < 0 || n >= len(m.rotors) {
+		return fmt.Errorf("%w: %d of %d rotors stationary", ErrWrongPart, n, len(m.rotors))
+	}
+	m.stationary = n
+	return nil
+}
 
+// NewM4 arranges the rotors of a 4-rotor naval Enigma M4: a thin
+// reflector ("B-thin" or "C-thin"), a Greek rotor ("Beta" or "Gamma")
+// that sits next to the reflector and never steps, and 3 rotors
+// like NewMachine. SetRotors and SetRings take a 4th letter for the Greek rotor.
+func NewM4(reflector, greek, first, second, third string) *Machine {
+	m, err := BuildM4(reflector, greek, first, second, third)
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
 	return m
 }
 
+// BuildM4 arranges an M4 like NewM4, returning an error
+// where NewM4 logs one and returns nil.
+func BuildM4(reflector, greek, first, second, third string) (*Machine, error) {
+	if !ThinReflectors[reflector] {
+		return nil, fmt.Errorf("%w: M4 needs a thin reflector, not %q", ErrWrongPart, reflector)
+	}
+	if !GreekRotors[greek] {
+		return nil, &RotorError{Position: 4, Name: greek, Err: ErrWrongPart}
+	}
+
+	m, err := Build(reflector, first, second, third, greek)
+	if err != nil {
+		return nil, err
+	}
+	m.SetStationary(1)
+
+	return m, nil
+}
+
 func (m *Machine) EncryptBuffer(text []rune) []rune {
 	var output []rune
 	for _, letter := range text {
@@ -61,53 +245,173 @@ func (m *Machine) EncryptLetter(inLetter rune) rune {
 
 	// Through the plugboard
 	outPos := m.plugBoard[int(unicode.ToUpper(inLetter)-'A')]
-	var carry int
+
+	m.step()
+
+	if m.tables != nil {
+		outPos = int(m.tables.permutation(m)[outPos])
+	} else {
+		outPos = m.scramble(outPos)
+	}
+
+	// Back through the plugboard
+	return rune(m.plugReturn[outPos] + 'A')
+}
+
+// Scrambler returns where the entry wheel, rotors and reflector take
+// each contact position, 0 for 'A', with the rotors where they are now:
+// the target enigma.Machine without its plugboard, and without stepping.
+// A bombe tests plugboard guesses on these.
+func (m *Machine) Scrambler() [26]int {
+	var scrambler [26]int
+	if m.tables != nil {
+		for i, pos := range m.tables.permutation(m) {
+			scrambler[i] = int(pos)
+		}
+		return scrambler
+	}
+	for i := range scrambler {
+		scrambler[i] = m.scramble(i)
+	}
+	return scrambler
+}
+
+// scramble takes a contact position from the plugboard through the
+// entry wheel, the rotors as they are now, the reflector, and back
+// to the plugboard, the scrambler part of an Enigma.
+func (m *Machine) scramble(inPos int) int {
+	outPos := m.entry.In(inPos)
 
 	// Give the input letter to the first rotor as a contact position,
 	// which is 0 for 'A', 1 for 'B', 2 for 'C', etc etc
-	outPos, carry = m.rotor1.CipherFwd(outPos, 1, false)
-	outPos, carry = m.rotor2.CipherFwd(outPos, carry, false)
-	outPos, carry = m.rotor3.CipherFwd(outPos, carry, false)
+	for _, r := range m.rotors {
+		outPos, _ = r.CipherFwd(outPos, 0, false)
+	}
 
 	outPos = m.reflector.Reflect(outPos)
 
-	outPos = m.rotor3.CipherBkwd(outPos, false)
-	outPos = m.rotor2.CipherBkwd(outPos, false)
-	outPos = m.rotor1.CipherBkwd(outPos, false)
+	for i := len(m.rotors) - 1; i >= 0; i-- {
+		outPos = m.rotors[i].CipherBkwd(outPos, false)
+	}
+
+	return m.entry.Out(outPos)
+}
 
-	// Back through the plugboard
-	outPos = m.plugBoard[outPos]
+// step moves the rotors for one keypress, before current flows.
+// Double stepping has pawls, the other models carry from rotor to rotor
+// until the stationary rotors, and the Enigma G's last stepping rotor
+// carries to its reflector.
+func (m *Machine) step() {
+	if m.stepping == DoubleStepping {
+		m.doubleStep()
+		return
+	}
+
+	carry := 1
+	for _, r := range m.steppingRotors() {
+		carry = r.Advance(carry)
+	}
+	if m.stepping == CogStepping {
+		m.reflector.Steps = (m.reflector.Steps + carry) % 26
+	}
+}
 
-	return rune(outPos + 'A')
+// steppingRotors are the rotors that can step, all but the stationary ones
+func (m *Machine) steppingRotors() []*rotor.Rotor {
+	return m.rotors[:len(m.rotors)-m.stationary]
 }
 
+// doubleStep moves the rotors the way the pawls of a real Enigma do.
+// Each stepping rotor but the fast one has a pawl riding on the notch ring
+// of the rotor to its right, the middle rotor's pawl on the fast rotor's,
+// the slow rotor's pawl on the middle rotor's.
+// A pawl that drops into a notch pushes both rotors it touches,
+// so a middle rotor that reaches its notch steps again on the next keypress.
+// Every decision depends on where the rotors were before the keypress,
+// so work from the slow end, stepping each rotor after its
+// left neighbor has looked at its notch.
+func (m *Machine) doubleStep() {
+	stepping := m.steppingRotors()
+	last := len(stepping) - 1
+	for i := last; i > 0; i-- {
+		if stepping[i-1].AtNotch() || (i < last && stepping[i].AtNotch()) {
+			stepping[i].Step()
+		}
+	}
+	stepping[0].Step()
+}
+
+// Plugboard plugs Stecker cables into the target enigma.Machine,
+// one for each pair of letters in swaps, like "AB".
+// It logs the problem and leaves the plugboard as it was
+// where SetPlugboard returns an error.
 func (m *Machine) Plugboard(swaps ...string) {
+	if err := m.SetPlugboard(swaps...); err != nil {
+		log.Print(err)
+	}
+}
 
-	for i := range m.plugBoard {
-		m.plugBoard[i] = i
+// SetPlugboard plugs cables like Plugboard, returning an error
+// for more than MaxCables pairs, a pair that isn't two letters,
+// or a letter in more than one pair. The plugboard only changes
+// if all the pairs are good.
+func (m *Machine) SetPlugboard(swaps ...string) error {
+	if len(swaps) > MaxCables {
+		return fmt.Errorf("%w: %d, a plugboard takes %d", ErrTooManyCables, len(swaps), MaxCables)
+	}
+
+	var board [26]int
+	for i := range board {
+		board[i] = i
 	}
 
 	for _, swap := range swaps {
-		if len(swap) != 2 {
-			continue
+		a, b, err := plugPair(swap)
+		if err != nil {
+			return err
+		}
+		if board[a] != a || board[b] != b {
+			return &PlugError{Plug: swap, Err: ErrLetterUsedTwice}
 		}
-		m.plugBoard[unicode.ToUpper(rune(swap[0]))-'A'] =
-			int(unicode.ToUpper(rune(swap[1])) - 'A')
-		m.plugBoard[unicode.ToUpper(rune(swap[1]))-'A'] =
-			int(unicode.ToUpper(rune(swap[0])) - 'A')
+		board[a], board[b] = b, a
 	}
+
+	// Stecker cables swap both ways
+	m.plugBoard = board
+	m.plugReturn = board
+
+	return nil
+}
+
+// plugPair turns a pair of letters like "AB" into contact positions
+func plugPair(swap string) (int, int, error) {
+	letters := []rune(swap)
+	if len(letters) != 2 {
+		return 0, 0, &PlugError{Plug: swap, Err: ErrInvalidPlug}
+	}
+	a := int(unicode.ToUpper(letters[0]) - 'A')
+	b := int(unicode.ToUpper(letters[1]) - 'A')
+	if a < 0 || a > 25 || b < 0 || b > 25 {
+		return 0, 0, &PlugError{Plug: swap, Err: ErrInvalidPlug}
+	}
+	if a == b {
+		return 0, 0, &PlugError{Plug: swap, Err: ErrLetterUsedTwice}
+	}
+	return a, b, nil
 }
 
 // SetRotors metaphorically turns the target enigma.Machine's
-// rotor representations to the first three letters of the settings argument.
-// The settings variable should be at least 3 letters long, [A-Z].
+// rotor representations to the letters of the settings argument,
+// fast rotor first. The settings variable should have a letter [A-Z]
+// for each rotor, 3 letters for NewMachine, 4 letters for an M4,
+// where the 4th letter sets the Greek rotor.
 func (m *Machine) SetRotors(settings string) {
 
 	// Reset rotors to 0 position, just in case settings formal argument
 	// has a rune that doesn't fit.
-	m.rotor1.Steps = 0
-	m.rotor2.Steps = 0
-	m.rotor3.Steps = 0
+	for _, r := range m.rotors {
+		r.Steps = 0
+	}
 
 	for i, letter := range settings {
 		setting := int(unicode.ToUpper(letter))
@@ -116,15 +420,81 @@ func (m *Machine) SetRotors(settings string) {
 			continue
 		}
 		setting -= 'A'
-		switch i {
-		case 0:
-			m.rotor1.Steps = setting
-		case 1:
-			m.rotor2.Steps = setting
-		case 2:
-			m.rotor3.Steps = setting
-		default:
+		if i >= len(m.rotors) {
 			log.Printf("unused rotor %d  setting %c\n", i+1, setting+'A')
+			continue
+		}
+		m.rotors[i].Steps = setting
+	}
+}
+
+// SetRings sets the ring settings (Ringstellung) of the target enigma.Machine's
+// rotors to the letters of the rings argument, in the same
+// order as SetRotors. Ring settings move the wiring relative to the
+// letters and notches, so call SetRotors for the starting positions.
+func (m *Machine) SetRings(rings string) {
+
+	m.tables = nil
+	for _, r := range m.rotors {
+		r.Ring = 0
+	}
+
+	for i, letter := range rings {
+		ring := int(unicode.ToUpper(letter))
+		if ring < 'A' || ring > 'Z' {
+			log.Printf("Ignoring bad ring setting %c\n", ring)
+			continue
+		}
+		ring -= 'A'
+		if i >= len(m.rotors) {
+			log.Printf("unused rotor %d  ring setting %c\n", i+1, ring+'A')
+			continue
+		}
+		m.rotors[i].Ring = ring
+	}
+}
+
+// SetPositions turns the rotors like SetRotors, returning an error for
+// a letter outside [A-Z] or more letters than rotors. Rotors
+// without a letter go to 'A'. The rotors only move if all the letters are good.
+func (m *Machine) SetPositions(settings string) error {
+	steps, err := m.parseSettings(settings)
+	if err != nil {
+		return err
+	}
+	for i, r := range m.rotors {
+		r.Steps = steps[i]
+	}
+	return nil
+}
+
+// SetRingSettings sets the rings like SetRings, returning
+// the same errors as SetPositions.
+func (m *Machine) SetRingSettings(rings string) error {
+	ringSettings, err := m.parseSettings(rings)
+	if err != nil {
+		return err
+	}
+	for i, r := range m.rotors {
+		r.Ring = ringSettings[i]
+	}
+	m.tables = nil
+	return nil
+}
+
+// parseSettings turns a string of letters into one number
+// for each of the target enigma.Machine's rotors
+func (m *Machine) parseSettings(settings string) ([]int, error) {
+	numbers := make([]int, len(m.rotors))
+	for i, letter := range []rune(settings) {
+		if i >= len(m.rotors) {
+			return nil, &SettingError{Setting: settings, Position: i + 1, Err: ErrTooManySettings}
+		}
+		setting := unicode.ToUpper(letter)
+		if setting < 'A' || setting > 'Z' {
+			return nil, &SettingError{Setting: settings, Position: i + 1, Err: ErrBadSetting}
 		}
+		numbers[i] = int(setting - 'A')
 	}
+	return numbers, nil
 }
@@ -0,0 +1,83 @@
+package enigma
+
+import "testing"
+
+// Published Enigma I vectors: rotors I-II-III, slow rotor first,
+// reflector B, no plugboard
+func TestEncryptBufferVectors(t *testing.T) {
+	tests := []struct {
+		rings, positions string // fast rotor first
+		plaintext, want  string
+	}{
+		{"AAA", "AAA", "AAAAA", "BDZGO"},
+		{"BBB", "AAA", "AAAAA", "EWTYX"},
+	}
+	for _, tt := range tests {
+		m, err := Build("B", "III", "II", "I")
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.SetStepping(DoubleStepping)
+		if err := m.SetRingSettings(tt.rings); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SetPositions(tt.positions); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(m.EncryptBuffer([]rune(tt.plaintext))); got != tt.want {
+			t.Errorf("rings %s positions %s: %s encrypts to %s, want %s",
+				tt.rings, tt.positions, tt.plaintext, got, tt.want)
+		}
+	}
+}
+
+// The middle rotor steps twice in a row: ADU, ADV, AEW, BFX,
+// slow rotor first
+func TestDoubleStep(t *testing.T) {
+	m, err := Build("B", "III", "II", "I")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetStepping(DoubleStepping)
+	if err := m.SetPositions("UDA"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"VDA", "WEA", "XFB"} {
+		m.EncryptLetter('A')
+		if got := m.State().Positions; got != want {
+			t.Fatalf("positions %s, want %s", got, want)
+		}
+	}
+}
+
+// The Enigma G's fast rotor carries to the middle rotor when it steps
+// off each of its published G-312 turnovers
+func TestEnigmaGTurnovers(t *testing.T) {
+	tests := []struct {
+		rotors    []string // fast rotor first
+		turnovers string
+	}{
+		{[]string{"G-I", "G-II", "G-III"}, "ABCEFGIKLOPQSUVWZ"},
+		{[]string{"G-II", "G-III", "G-I"}, "ACDFGHKMNQSTVYZ"},
+		{[]string{"G-III", "G-II", "G-I"}, "AEFHKMNRUWX"},
+	}
+	for _, tt := range tests {
+		m, err := BuildModel("G", tt.rotors...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []rune
+		for position := 'A'; position <= 'Z'; position++ {
+			if err := m.SetPositions(string(position) + "AA"); err != nil {
+				t.Fatal(err)
+			}
+			m.EncryptLetter('A')
+			if m.State().Positions[1] != 'A' {
+				got = append(got, position)
+			}
+		}
+		if string(got) != tt.turnovers {
+			t.Errorf("%s turns over at %s, want %s", tt.rotors[0], string(got), tt.turnovers)
+		}
+	}
+}
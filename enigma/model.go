@@ -0,0 +1,119 @@
+package enigma
+
+import (
+	"fmt"
+	"log"
+)
+
+// Model describes an Enigma variant: which reflector, entry wheel
+// and rotors it came with, and how its rotors step.
+type Model struct {
+	Reflector  string   // one of rotor.Reflectors
+	EntryWheel string   // one of rotor.EntryWheels
+	Rotors     []string // rotor.Rotors names, fast rotor first
+	Stationary int      // rotors next to the reflector that never step
+	Stepping   Stepping
+}
+
+// Models are the preset Enigma variants NewModel knows about
+var Models = map[string]*Model{
+	// Enigma I, the 3-rotor army and air force machine
+	"I": {
+		Reflector:  "B",
+		EntryWheel: "ABC",
+		Rotors:     []string{"III", "II", "I"},
+		Stepping:   DoubleStepping,
+	},
+	// M4, the 4-rotor naval machine, with a stationary Greek rotor
+	"M4": {
+		Reflector:  "B-thin",
+		EntryWheel: "ABC",
+		Rotors:     []string{"III", "II", "I", "Beta"},
+		Stationary: 1,
+		Stepping:   DoubleStepping,
+	},
+	// Commercial Enigma D, no plugboard, settable reflector
+	"D": {
+		Reflector:  "K",
+		EntryWheel: "QWERTZ",
+		Rotors:     []string{"K-III", "K-II", "K-I"},
+		Stepping:   DoubleStepping,
+	},
+	// Commercial Enigma K, the same wiring as the D
+	"K": {
+		Reflector:  "K",
+		EntryWheel: "QWERTZ",
+		Rotors:     []string{"K-III", "K-II", "K-I"},
+		Stepping:   DoubleStepping,
+	},
+	// Swiss-K, an Enigma K with rotors rewired for the Swiss army
+	"SwissK": {
+		Reflector:  "K",
+		EntryWheel: "QWERTZ",
+		Rotors:     []string{"SK-III", "SK-II", "SK-I"},
+		Stepping:   DoubleStepping,
+	},
+	// Abwehr Enigma G, gear driven rotors with many notches
+	// and a reflector that steps too
+	"G": {
+		Reflector:  "G",
+		EntryWheel: "QWERTZ",
+		Rotors:     []string{"G-III", "G-II", "G-I"},
+		Stepping:   CogStepping,
+	},
+}
+
+// NewModel builds a Machine of the named model. Without rotor names it
+// uses the model's own rotors, otherwise the named rotors, fast rotor first.
+// SetRotors, SetRings and SetReflectorPosition still have to be called.
+func NewModel(name string, rotors ...string) *Machine {
+	m, err := BuildModel(name, rotors...)
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return m
+}
+
+// BuildModel builds a Machine like NewModel, returning an error
+// for an unknown model, a model whose entry wheel or stationary
+// rotors don't fit, or any error Build returns.
+func BuildModel(name string, rotors ...string) (*Machine, error) {
+	model, ok := Models[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownModel, name)
+	}
+	if len(rotors) == 0 {
+		rotors = model.Rotors
+	}
+
+	m, err := Build(model.Reflector, rotors...)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.UseEntryWheel(model.EntryWheel); err != nil {
+		return nil, err
+	}
+	if err := m.KeepStationary(model.Stationary); err != nil {
+		return nil, err
+	}
+	m.SetStepping(model.Stepping)
+
+	return m, nil
+}
+
+// RotorOrders lists every way of putting size of the named rotors
+// in a machine, fast rotor first, for searching rotor orders
+func RotorOrders(names []string, size int) [][]string {
+	if size == 0 {
+		return [][]string{nil}
+	}
+	var orders [][]string
+	for i, name := range names {
+		rest := append(append([]string(nil), names[:i]...), names[i+1:]...)
+		for _, order := range RotorOrders(rest, size-1) {
+			orders = append(orders, append([]string{name}, order...))
+		}
+	}
+	return orders
+}
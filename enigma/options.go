@@ -0,0 +1,156 @@
+package enigma
+
+import (
+	"enigmalike/rotor"
+	"fmt"
+)
+
+// Option configures a Machine that New builds
+type Option func(*options)
+
+// options collects what the Options ask for,
+// New checks it all when it builds the Machine
+type options struct {
+	model      string
+	reflector  string
+	rotors     []string
+	rings      string
+	positions  string
+	plugs      []string
+	uhr        int
+	entry      string
+	stationary int
+	stepping   *Stepping
+}
+
+// WithModel starts from one of the Models, as NewModel does.
+// Other options override the model's parts.
+func WithModel(name string) Option {
+	return func(o *options) { o.model = name }
+}
+
+// WithReflector uses the named reflector, one of rotor.Reflectors.
+// Without it New uses reflector B, or the model's.
+func WithReflector(name string) Option {
+	return func(o *options) { o.reflector = name }
+}
+
+// WithRotors uses the named rotors, fast rotor first
+func WithRotors(names ...string) Option {
+	return func(o *options) { o.rotors = names }
+}
+
+// WithRings sets the ring settings, a letter per rotor, as SetRingSettings does
+func WithRings(rings string) Option {
+	return func(o *options) { o.rings = rings }
+}
+
+// WithPositions turns the rotors, a letter per rotor, as SetPositions does
+func WithPositions(positions string) Option {
+	return func(o *options) { o.positions = positions }
+}
+
+// WithPlugs plugs in Stecker cables, as SetPlugboard does
+func WithPlugs(pairs ...string) Option {
+	return func(o *options) { o.plugs = pairs }
+}
+
+// WithUhr plugs 10 pairs into the Uhr at setting, as SetUhr does
+func WithUhr(setting int, pairs ...string) Option {
+	return func(o *options) {
+		o.uhr = setting
+		o.plugs = pairs
+	}
+}
+
+// WithEntryWheel uses the named entry wheel, one of rotor.EntryWheels
+func WithEntryWheel(name string) Option {
+	return func(o *options) { o.entry = name }
+}
+
+// WithStationary keeps the last n rotors from stepping, as SetStationary does
+func WithStationary(n int) Option {
+	return func(o *options) { o.stationary = n }
+}
+
+// WithStepping chooses the stepping model, as SetStepping does
+func WithStepping(stepping Stepping) Option {
+	return func(o *options) { o.stepping = &stepping }
+}
+
+// WithKey uses the stepping model, reflector, rotors, rings,
+// positions and plugs of k, and keeps a Greek rotor from stepping
+func WithKey(k *Key) Option {
+	return func(o *options) {
+		stepping := k.Stepping
+		o.stepping = &stepping
+		o.stationary = k.greek()
+		o.reflector = k.Reflector
+		o.rotors = k.Rotors
+		o.rings = k.Rings
+		o.positions = k.Positions
+		o.plugs = k.Plugs
+	}
+}
+
+// New builds a Machine, set up and ready to encrypt, from options.
+// Any problem with them comes back as one of the errors
+// Build, BuildModel and the error-returning setters return.
+func New(opts ...Option) (*Machine, error) {
+	o := &options{uhr: -1, stationary: -1}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var m *Machine
+	var err error
+	if o.model != "" {
+		m, err = BuildModel(o.model, o.rotors...)
+		if err == nil && o.reflector != "" {
+			if r := rotor.ChooseReflector(o.reflector); r != nil {
+				m.SetReflector(r)
+			} else {
+				err = fmt.Errorf("%w %q", ErrUnknownReflector, o.reflector)
+			}
+		}
+	} else {
+		reflector := o.reflector
+		if reflector == "" {
+			reflector = "B"
+		}
+		m, err = Build(reflector, o.rotors...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if o.entry != "" {
+		if err := m.UseEntryWheel(o.entry); err != nil {
+			return nil, err
+		}
+	}
+	if o.stationary >= 0 {
+		if err := m.KeepStationary(o.stationary); err != nil {
+			return nil, err
+		}
+	}
+	if o.stepping != nil {
+		m.SetStepping(*o.stepping)
+	}
+	if err := m.SetRingSettings(o.rings); err != nil {
+		return nil, err
+	}
+	if err := m.SetPositions(o.positions); err != nil {
+		return nil, err
+	}
+	if o.uhr >= 0 {
+		err = m.SetUhr(o.uhr, o.plugs...)
+	} else {
+		err = m.SetPlugboard(o.plugs...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,271 @@
+package enigma
+
+import (
+	"enigmalike/rotor"
+	"runtime"
+	"sync"
+)
+
+// Advance moves the target enigma.Machine's rotors, and the Enigma G's
+// reflector, to where n more keypresses would leave them, without
+// encrypting anything. Notch, cog and legacy stepping work the positions
+// out directly, and so does double stepping unless a rotor has two
+// notches next to each other. Then the rotors go round in a cycle
+// no longer than 26 positions of each rotor but the last,
+// which Advance finds and skips over.
+// n less than 1 does nothing.
+func (m *Machine) Advance(n int) {
+	if n < 1 {
+		return
+	}
+	switch m.stepping {
+	case DoubleStepping:
+		if separateNotches(m.steppingRotors()) {
+			m.advanceDouble(n)
+		} else {
+			m.advanceCycle(n)
+		}
+	default:
+		m.advanceNotch(n)
+	}
+}
+
+// wrapNotch is where a legacy stepping rotor carries,
+// stepping off 'Z' round to 'A'
+var wrapNotch = []int{25}
+
+// advanceNotch is Advance for notch, cog and legacy stepping. Each rotor
+// steps as many times as the rotor to its right stepped off a notch.
+func (m *Machine) advanceNotch(n int) {
+	for _, r := range m.steppingRotors() {
+		notches := r.Notches
+		if r.WrapCarry {
+			notches = wrapNotch
+		}
+		carries := notchPassings(notches, r.Steps, n)
+		r.Steps = (r.Steps + n) % 26
+		n = carries
+	}
+	if m.stepping == CogStepping {
+		m.reflector.Steps = (m.reflector.Steps + n) % 26
+	}
+}
+
+// notchPassings counts how many of n steps from position start
+// step off one of notches
+func notchPassings(notches []int, start, n int) int {
+	count := 0
+	for _, notch := range notches {
+		// the first step off this notch is step d+1
+		d := (notch - start + 26) % 26
+		if n > d {
+			count += (n-d-1)/26 + 1
+		}
+	}
+	return count
+}
+
+// separateNotches reports whether no rotor but the last has notches
+// next to each other. Then a rotor that a kick from its right neighbor
+// leaves at a notch always takes its double step on the next keypress,
+// before its neighbor can kick it again, which advanceDouble counts on.
+func separateNotches(stepping []*rotor.Rotor) bool {
+	for _, r := range stepping[:len(stepping)-1] {
+		for _, notch := range r.Notches {
+			if isNotch(r.Notches, (notch+1)%26) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isNotch(notches []int, position int) bool {
+	for _, notch := range notches {
+		if notch == position {
+			return true
+		}
+	}
+	return false
+}
+
+// advanceDouble is Advance for double stepping, see doubleStep.
+// The fast rotor steps every keypress. Each rotor to its left
+// gets kicked one step whenever its right neighbor steps off a notch.
+// The rotors between the fast rotor and the last one also step off
+// a notch on the keypress after a kick leaves them there, the double step.
+// departures and position look back one keypress for each rotor
+// they work through, so this takes time in the square of the rotors.
+func (m *Machine) advanceDouble(n int) {
+	stepping := m.steppingRotors()
+	last := len(stepping) - 1
+
+	var start []int
+	for _, r := range stepping {
+		start = append(start, r.Steps)
+	}
+	atStart := func(i int) bool { return isNotch(stepping[i].Notches, start[i]) }
+
+	// kicks counts the kicks rotor i gets from its right neighbor
+	// in keypresses 1 through t, less one if rotor i was at a notch
+	// and stepped off it at keypress 1 anyway.
+	// Kicks leave rotor i at a notch landings times.
+	var departures func(i, t int) int
+	kicks := func(i, t int) int {
+		if t < 1 {
+			return 0
+		}
+		k := departures(i-1, t)
+		if atStart(i) && atStart(i-1) {
+			k--
+		}
+		return k
+	}
+	landed := func(i, t int) int {
+		from := start[i]
+		if atStart(i) {
+			from++
+		}
+		return landings(stepping[i].Notches, from, kicks(i, t))
+	}
+
+	// departures counts how often rotor i, not the last, steps off
+	// a notch in keypresses 1 through t. A middle rotor at a notch
+	// always steps off it on the next keypress.
+	departures = func(i, t int) int {
+		if t < 1 {
+			return 0
+		}
+		if i == 0 {
+			return notchPassings(stepping[0].Notches, start[0], t)
+		}
+		count := landed(i, t-1)
+		if atStart(i) {
+			count++
+		}
+		return count
+	}
+
+	for i, r := range stepping {
+		steps := n
+		switch {
+		case i == 0:
+		case i == last:
+			steps = departures(i-1, n)
+		default:
+			steps = kicks(i, n) + landed(i, n-1)
+			if atStart(i) {
+				steps++
+			}
+		}
+		r.Steps = (start[i] + steps) % 26
+	}
+}
+
+// landings counts how many of j kicks, one step each, leave a rotor
+// that starts at position from, not a notch, at one of notches.
+// Every landing steps on to the next position before the next kick,
+// so each time around takes 26 kicks less one per notch.
+func landings(notches []int, from, j int) int {
+	if j < 1 || len(notches) == 0 {
+		return 0
+	}
+	aRound := 26 - len(notches)
+	count := (j / aRound) * len(notches)
+	position := from % 26
+	for k := j % aRound; k > 0; k-- {
+		position = (position + 1) % 26
+		if isNotch(notches, position) {
+			count++
+			position = (position + 1) % 26
+		}
+	}
+	return count
+}
+
+// advanceCycle is Advance for double stepping with neighboring notches.
+// Where all the stepping rotors but the last are decides everything
+// that happens next, so after at most 26 positions of each of them
+// they repeat a cycle. It steps them until they come back to positions
+// they've been at, skips as many whole cycles as fit, and steps
+// the rest. The last rotor just counts its kicks.
+func (m *Machine) advanceCycle(n int) {
+	stepping := m.steppingRotors()
+	last := len(stepping) - 1
+
+	type visit struct{ keypress, kicks int }
+	seen := make(map[int]visit)
+	kicks := 0
+	for t := 0; t < n; t++ {
+		if seen != nil {
+			key := 0
+			for _, r := range stepping[:last] {
+				key = key*26 + r.Steps
+			}
+			if v, ok := seen[key]; ok {
+				cycles := (n - t) / (t - v.keypress)
+				t += cycles * (t - v.keypress)
+				kicks += cycles * (kicks - v.kicks)
+				seen = nil
+				if t == n {
+					break
+				}
+			} else {
+				seen[key] = visit{t, kicks}
+			}
+		}
+
+		if stepping[last-1].AtNotch() {
+			kicks++
+		}
+		for i := last - 1; i > 0; i-- {
+			if stepping[i-1].AtNotch() || stepping[i].AtNotch() {
+				stepping[i].Step()
+			}
+		}
+		stepping[0].Step()
+	}
+	stepping[last].Steps = (stepping[last].Steps + kicks) % 26
+}
+
+// EncryptParallel encrypts text like EncryptBuffer, splitting it into
+// chunks that workers encrypt at once on Clones of the target
+// enigma.Machine, each Advanced to the start of its chunk.
+// workers less than 1 means one per CPU. The target ends up
+// where EncryptBuffer would leave it.
+func (m *Machine) EncryptParallel(text []rune, workers int) []rune {
+	var letters []rune
+	for _, letter := range text {
+		if letter >= 'A' && letter <= 'Z' {
+			letters = append(letters, letter)
+		}
+	}
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunk := (len(letters) + workers - 1) / workers
+	if chunk < 1 {
+		return letters
+	}
+
+	output := make([]rune, len(letters))
+	var wg sync.WaitGroup
+	for begin := 0; begin < len(letters); begin += chunk {
+		end := begin + chunk
+		if end > len(letters) {
+			end = len(letters)
+		}
+		wg.Add(1)
+		go func(c *Machine, begin, end int) {
+			defer wg.Done()
+			c.Advance(begin)
+			for i := begin; i < end; i++ {
+				output[i] = c.EncryptLetter(letters[i])
+			}
+		}(m.Clone(), begin, end)
+	}
+	wg.Wait()
+
+	m.Advance(len(letters))
+	return output
+}
@@ -0,0 +1,118 @@
+package enigma
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Rotors with one notch, two, and the Enigma G's many
+var seekRotors = []string{"I", "II", "III", "IV", "V", "VI", "VII", "VIII", "G-I", "G-II", "G-III"}
+
+// randomMachine sets up a Machine of size random rotors,
+// some of them maybe stationary, at random rings and positions
+func randomMachine(t *testing.T, r *rand.Rand, size int, stepping Stepping) *Machine {
+	var names []string
+	for _, i := range r.Perm(len(seekRotors))[:size] {
+		names = append(names, seekRotors[i])
+	}
+	m, err := Build("B", names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetStepping(stepping)
+	if err := m.KeepStationary(r.Intn(size)); err != nil {
+		t.Fatal(err)
+	}
+
+	var rings, positions []rune
+	for i := 0; i < size; i++ {
+		rings = append(rings, rune('A'+r.Intn(26)))
+		positions = append(positions, rune('A'+r.Intn(26)))
+	}
+	if err := m.SetRingSettings(string(rings)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetPositions(string(positions)); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestAdvance(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, stepping := range Steppings {
+		for size := 1; size <= 5; size++ {
+			for trial := 0; trial < 100; trial++ {
+				m := randomMachine(t, r, size, stepping)
+				stepped := m.Clone()
+				before := m.State()
+
+				n := r.Intn(3000)
+				m.Advance(n)
+				for i := 0; i < n; i++ {
+					stepped.step()
+				}
+				if got, want := m.State(), stepped.State(); !reflect.DeepEqual(got, want) {
+					t.Fatalf("%v stepping from %+v, Advance(%d) gets to %+v, stepping gets to %+v",
+						stepping, before, n, got, want)
+				}
+			}
+		}
+	}
+}
+
+// Far enough that double stepping with the Enigma G's neighboring
+// notches goes round its cycle, and legacy stepping wraps every rotor
+func TestAdvanceFar(t *testing.T) {
+	for _, stepping := range []Stepping{DoubleStepping, LegacyStepping} {
+		for _, names := range [][]string{
+			{"G-I", "G-II", "G-III"},
+			{"G-II", "G-III", "G-I", "I"},
+			{"III", "G-I", "II"},
+		} {
+			m, err := Build("B", names...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.SetStepping(stepping)
+			if err := m.SetPositions("QUZA"[:len(names)]); err != nil {
+				t.Fatal(err)
+			}
+			stepped := m.Clone()
+
+			n := 500000
+			m.Advance(n)
+			for i := 0; i < n; i++ {
+				stepped.step()
+			}
+			if got, want := m.State().Positions, stepped.State().Positions; got != want {
+				t.Errorf("%v stepping %v: Advance(%d) gets to %s, stepping gets to %s",
+					stepping, names, n, got, want)
+			}
+		}
+	}
+}
+
+func TestEncryptParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	text := make([]rune, 5000)
+	for i := range text {
+		text[i] = rune('A' + r.Intn(26))
+	}
+	for _, stepping := range Steppings {
+		for workers := 1; workers <= 7; workers++ {
+			m := randomMachine(t, r, 3, stepping)
+			serial := m.Clone()
+
+			got := string(m.EncryptParallel(text, workers))
+			if want := string(serial.EncryptBuffer(text)); got != want {
+				t.Errorf("%v stepping, %d workers: EncryptParallel differs from EncryptBuffer", stepping, workers)
+			}
+			if !reflect.DeepEqual(m.State(), serial.State()) {
+				t.Errorf("%v stepping, %d workers: EncryptParallel leaves the machine at %+v, not %+v",
+					stepping, workers, m.State(), serial.State())
+			}
+		}
+	}
+}
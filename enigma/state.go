@@ -0,0 +1,173 @@
+package enigma
+
+import (
+	"enigmalike/rotor"
+	"fmt"
+	"strings"
+)
+
+// State is everything about a Machine that changes how it encrypts,
+// including where its rotors have stepped to, in a form that
+// encoding/json can save. Letters and rotors are fast rotor first.
+type State struct {
+	Rotors            []string `json:"rotors"`
+	Rings             string   `json:"rings"`
+	Positions         string   `json:"positions"`
+	Reflector         string   `json:"reflector,omitempty"`        // a rotor.Reflectors name
+	ReflectorWiring   string   `json:"reflector_wiring,omitempty"` // a reflector without a name, UKW-D say
+	ReflectorPosition string   `json:"reflector_position"`
+	EntryWheel        string   `json:"entry_wheel"`
+	Plugboard         string   `json:"plugboard"`             // what keys A through Z go to
+	PlugReturn        string   `json:"plug_return,omitempty"` // with the Uhr, what the rotors' A through Z light
+	Stationary        int      `json:"stationary,omitempty"`
+	Stepping          string   `json:"stepping"`
+}
+
+// Clone returns a copy of the target enigma.Machine, at the same
+// positions, that steps and gets set up without changing the original.
+func (m *Machine) Clone() *Machine {
+	c := *m
+	c.rotors = make([]*rotor.Rotor, len(m.rotors))
+	for i, r := range m.rotors {
+		// Notches never change once a rotor is wired, so they can be shared
+		copied := *r
+		c.rotors[i] = &copied
+	}
+	reflector := *m.reflector
+	c.reflector = &reflector
+	return &c
+}
+
+// State returns a snapshot of the target enigma.Machine for Restore
+func (m *Machine) State() *State {
+	s := &State{
+		Reflector:         m.reflector.Name,
+		ReflectorPosition: string(rune(m.reflector.Steps + 'A')),
+		Plugboard:         permutationLetters(m.plugBoard),
+		Stationary:        m.stationary,
+		Stepping:          m.stepping.String(),
+	}
+	if s.Reflector == "" {
+		s.ReflectorWiring = m.reflector.Wiring()
+	}
+	if m.plugReturn != m.plugBoard {
+		s.PlugReturn = permutationLetters(m.plugReturn)
+	}
+	for name, entry := range rotor.EntryWheels {
+		if entry == m.entry {
+			s.EntryWheel = name
+		}
+	}
+
+	var rings, positions []rune
+	for _, r := range m.rotors {
+		s.Rotors = append(s.Rotors, r.Name)
+		rings = append(rings, rune(r.Ring+'A'))
+		positions = append(positions, rune(r.Steps+'A'))
+	}
+	s.Rings = string(rings)
+	s.Positions = string(positions)
+
+	return s
+}
+
+// Restore sets up the target enigma.Machine exactly as State
+// found a Machine. The target can be a zero Machine,
+// and only changes if all of s is good.
+func (m *Machine) Restore(s *State) error {
+	reflector := s.Reflector
+	if reflector == "" && s.ReflectorWiring != "" {
+		reflector = "B" // replaced by the wiring below
+	}
+	restored, err := Build(reflector, s.Rotors...)
+	if err != nil {
+		return err
+	}
+
+	if s.ReflectorWiring != "" {
+		if restored.reflector, err = rotor.NewReflectorWiring(s.ReflectorWiring); err != nil {
+			return err
+		}
+	}
+	if restored.reflector.Steps, err = parseLetter(s.ReflectorPosition); err != nil {
+		return err
+	}
+
+	if restored.entry = rotor.ChooseEntryWheel(s.EntryWheel); restored.entry == nil {
+		return fmt.Errorf("%w: no entry wheel %q", ErrWrongPart, s.EntryWheel)
+	}
+	if s.Stationary < 0 || s.Stationary >= len(restored.rotors) {
+		return fmt.Errorf("%w: %d of %d rotors stationary", ErrWrongPart, s.Stationary, len(restored.rotors))
+	}
+	restored.stationary = s.Stationary
+	stepping, ok := Steppings[s.Stepping]
+	if !ok {
+		return fmt.Errorf("no stepping model %q", s.Stepping)
+	}
+	restored.SetStepping(stepping)
+
+	if err := restored.SetRingSettings(s.Rings); err != nil {
+		return err
+	}
+	if err := restored.SetPositions(s.Positions); err != nil {
+		return err
+	}
+
+	if restored.plugBoard, err = parsePermutation(s.Plugboard); err != nil {
+		return err
+	}
+	restored.plugReturn = restored.plugBoard
+	if s.PlugReturn != "" {
+		if restored.plugReturn, err = parsePermutation(s.PlugReturn); err != nil {
+			return err
+		}
+	}
+	for key, contact := range restored.plugBoard {
+		if restored.plugReturn[contact] != key {
+			return &PlugError{Plug: s.PlugReturn, Err: ErrInvalidPlug}
+		}
+	}
+
+	*m = *restored
+	return nil
+}
+
+// parseLetter turns a single letter setting into a number, 'A' is 0
+func parseLetter(setting string) (int, error) {
+	letters := []rune(strings.ToUpper(setting))
+	if len(letters) != 1 || letters[0] < 'A' || letters[0] > 'Z' {
+		return 0, &SettingError{Setting: setting, Position: 1, Err: ErrBadSetting}
+	}
+	return int(letters[0] - 'A'), nil
+}
+
+// permutationLetters writes a substitution as the letters A through Z go to
+func permutationLetters(p [26]int) string {
+	var letters [26]byte
+	for i, out := range p {
+		letters[i] = byte(out + 'A')
+	}
+	return string(letters[:])
+}
+
+// parsePermutation reads a substitution that permutationLetters wrote
+func parsePermutation(letters string) ([26]int, error) {
+	var p [26]int
+	var used [26]bool
+	letters = strings.ToUpper(letters)
+	if len(letters) != 26 {
+		return p, &PlugError{Plug: letters, Err: ErrInvalidPlug}
+	}
+	for i := range letters {
+		out := int(letters[i]) - 'A'
+		if out < 0 || out > 25 {
+			return p, &PlugError{Plug: letters, Err: ErrInvalidPlug}
+		}
+		if used[out] {
+			return p, &PlugError{Plug: letters, Err: ErrLetterUsedTwice}
+		}
+		used[out] = true
+		p[i] = out
+	}
+	return p, nil
+}
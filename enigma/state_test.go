@@ -0,0 +1,76 @@
+package enigma
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// A State saved through encoding/json part way through a message
+// restores a Machine that carries on exactly where the first one was
+func TestStateRestore(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"plugs", []Option{WithRotors("III", "II", "I"), WithRings("BCD"), WithPositions("QEV"), WithPlugs("AB", "CD", "EF")}},
+		{"Uhr", []Option{WithRotors("III", "II", "I"), WithPositions("QEV"), WithUhr(13, uhrPairs...)}},
+		{"Enigma G", []Option{WithModel("G"), WithPositions("ZZZ")}},
+	}
+	plaintext := make([]rune, 1000)
+	for i := range plaintext {
+		plaintext[i] = rune('A' + i%26)
+	}
+	for _, tt := range tests {
+		m, err := New(tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.EncryptBuffer(plaintext[:377])
+
+		saved, err := json.Marshal(m.State())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s State
+		if err := json.Unmarshal(saved, &s); err != nil {
+			t.Fatal(err)
+		}
+		restored := &Machine{}
+		if err := restored.Restore(&s); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		want := string(m.EncryptBuffer(plaintext[377:]))
+		if got := string(restored.EncryptBuffer(plaintext[377:])); got != want {
+			t.Errorf("%s: restored machine encrypts %s, want %s", tt.name, got, want)
+		}
+	}
+}
+
+// Setting up or stepping a Clone leaves the original alone
+func TestCloneIndependent(t *testing.T) {
+	m, err := New(WithRotors("III", "II", "I"), WithPositions("QEV"), WithPlugs("AB", "CD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := m.State()
+
+	c := m.Clone()
+	for i := range m.rotors {
+		if c.rotors[i] == m.rotors[i] {
+			t.Errorf("clone shares rotor %d", i)
+		}
+	}
+	if err := c.SetPlugboard("XY"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetRingSettings("ZZZ"); err != nil {
+		t.Fatal(err)
+	}
+	c.EncryptBuffer([]rune("HELLOWORLD"))
+
+	if after := m.State(); !reflect.DeepEqual(before, after) {
+		t.Errorf("original went from %+v to %+v", before, after)
+	}
+}
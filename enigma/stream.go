@@ -0,0 +1,163 @@
+package enigma
+
+import (
+	"enigmalike/alphabet"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LetterCipher encrypts one letter [A-Z] at a time,
+// as Machine and typex.Machine do
+type LetterCipher interface {
+	EncryptLetter(rune) rune
+}
+
+// NonLetters says what a Reader or Writer does with text
+// that isn't a letter A through Z, in either case
+type NonLetters int
+
+const (
+	// DropNonLetters leaves out everything but letters,
+	// the way an operator would type a message
+	DropNonLetters NonLetters = iota
+	// PassNonLetters copies everything but letters unencrypted
+	PassNonLetters
+	// TransliterateNonLetters spells out what it can
+	// the way German operators did, Ä as AE, ß as SS, '.' as X,
+	// digits as words, and drops the rest
+	TransliterateNonLetters
+)
+
+// NonLetterModes maps command line names to NonLetters
+var NonLetterModes = map[string]NonLetters{
+	"drop":     DropNonLetters,
+	"pass":     PassNonLetters,
+	"translit": TransliterateNonLetters,
+}
+
+// transliterations are the spellings TransliterateNonLetters uses,
+// along with alphabet.Spellings for umlauts and ß
+var transliterations = map[rune]string{
+	'.': "X", ',': "Y", '?': "UD",
+	'0': "NULL", '1': "EINS", '2': "ZWO", '3': "DREI", '4': "VIER",
+	'5': "FUENF", '6': "SECHS", '7': "SIEBEN", '8': "ACHT", '9': "NEUN",
+}
+
+// streamBufferSize is how much a Reader reads from its source at a time
+const streamBufferSize = 4096
+
+// Reader encrypts the text it reads from another io.Reader
+type Reader struct {
+	src    io.Reader
+	cipher LetterCipher
+	mode   NonLetters
+	in     [streamBufferSize]byte
+	inLen  int    // bytes in "in", the last few can be part of a rune
+	buf    []byte // encrypted text, reused for each read from src
+	out    []byte // the part of buf that Read hasn't returned yet
+	err    error  // from src, returned once out is empty
+}
+
+// NewReader returns a Reader that encrypts what it reads from src
+// with cipher, usually a Machine, handling non-letters as mode says.
+func NewReader(src io.Reader, cipher LetterCipher, mode NonLetters) *Reader {
+	return &Reader{src: src, cipher: cipher, mode: mode}
+}
+
+// Read fills p with encrypted text
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		n, err := r.src.Read(r.in[r.inLen:])
+		r.inLen += n
+		r.err = err
+
+		var used int
+		r.buf, used = encryptText(r.buf[:0], r.in[:r.inLen], r.cipher, r.mode, err != nil)
+		r.inLen = copy(r.in[:], r.in[used:r.inLen])
+		r.out = r.buf
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// Writer encrypts the text written to it, and writes it to another io.Writer
+type Writer struct {
+	dst     io.Writer
+	cipher  LetterCipher
+	mode    NonLetters
+	partial []byte // the start of a rune split between Write calls
+	buf     []byte // encrypted text, reused for each Write
+}
+
+// NewWriter returns a Writer that encrypts with cipher, usually a Machine,
+// handling non-letters as mode says, and writes to dst.
+// Call Flush after the last Write.
+func NewWriter(dst io.Writer, cipher LetterCipher, mode NonLetters) *Writer {
+	return &Writer{dst: dst, cipher: cipher, mode: mode}
+}
+
+// Write encrypts p. A rune split across calls to Write
+// gets encrypted once the rest of it arrives.
+func (w *Writer) Write(p []byte) (int, error) {
+	src := p
+	if len(w.partial) > 0 {
+		src = append(w.partial, p...)
+	}
+	var used int
+	w.buf, used = encryptText(w.buf[:0], src, w.cipher, w.mode, false)
+	w.partial = append(w.partial[:0], src[used:]...)
+
+	if _, err := w.dst.Write(w.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush encrypts what's left of a rune split between calls to Write,
+// which can only be an invalid UTF-8 sequence by now.
+func (w *Writer) Flush() error {
+	if len(w.partial) == 0 {
+		return nil
+	}
+	w.buf, _ = encryptText(w.buf[:0], w.partial, w.cipher, w.mode, true)
+	w.partial = w.partial[:0]
+	_, err := w.dst.Write(w.buf)
+	return err
+}
+
+// encryptText appends the encryption of src to dst. It returns
+// how many bytes of src it used, which is all of them unless src ends
+// part way through a rune and atEOF says more might be coming.
+func encryptText(dst, src []byte, cipher LetterCipher, mode NonLetters, atEOF bool) ([]byte, int) {
+	used := 0
+	for used < len(src) {
+		if !atEOF && !utf8.FullRune(src[used:]) {
+			break
+		}
+		letter, size := utf8.DecodeRune(src[used:])
+		upper := unicode.ToUpper(letter)
+
+		switch {
+		case upper >= 'A' && upper <= 'Z':
+			dst = append(dst, byte(cipher.EncryptLetter(upper)))
+		case mode == PassNonLetters:
+			dst = append(dst, src[used:used+size]...)
+		case mode == TransliterateNonLetters:
+			spelling, ok := alphabet.Spellings[upper]
+			if !ok {
+				spelling = transliterations[upper]
+			}
+			for _, spelled := range spelling {
+				dst = append(dst, byte(cipher.EncryptLetter(spelled)))
+			}
+		}
+		used += size
+	}
+	return dst, used
+}
@@ -0,0 +1,115 @@
+package enigma
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// shift encrypts each letter to the next one, so tests can see
+// which letters went through the cipher
+type shift struct{}
+
+func (shift) EncryptLetter(r rune) rune {
+	return 'A' + (r-'A'+1)%26
+}
+
+var streamTests = []struct {
+	mode        NonLetters
+	input, want string
+}{
+	{DropNonLetters, "Grüße, 1 Welt!", "HSFXFMU"},
+	{PassNonLetters, "Grüße, 1 Welt!", "HSüßF, 1 XFMU!"},
+	{TransliterateNonLetters, "Grüße, 1 Welt!", "HSVFTTFZFJOTXFMU"},
+	{TransliterateNonLetters, "Öl.", "PFMY"},
+}
+
+func TestWriter(t *testing.T) {
+	for _, tt := range streamTests {
+		var out bytes.Buffer
+		w := NewWriter(&out, shift{}, tt.mode)
+		if _, err := io.WriteString(w, tt.input); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("mode %d: %q encrypts to %q, want %q", tt.mode, tt.input, got, tt.want)
+		}
+	}
+}
+
+// Runes split between Write calls come out whole
+func TestWriterSplitRunes(t *testing.T) {
+	for _, tt := range streamTests {
+		var out bytes.Buffer
+		w := NewWriter(&out, shift{}, tt.mode)
+		for i := 0; i < len(tt.input); i++ {
+			if _, err := w.Write([]byte{tt.input[i]}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("mode %d, a byte at a time: %q encrypts to %q, want %q", tt.mode, tt.input, got, tt.want)
+		}
+	}
+}
+
+// Runes split between reads from the source come out whole
+func TestReader(t *testing.T) {
+	for _, tt := range streamTests {
+		for _, src := range []io.Reader{
+			strings.NewReader(tt.input),
+			iotest.OneByteReader(strings.NewReader(tt.input)),
+		} {
+			got, err := io.ReadAll(NewReader(src, shift{}, tt.mode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("mode %d: %q encrypts to %q, want %q", tt.mode, tt.input, string(got), tt.want)
+			}
+		}
+	}
+}
+
+func streamMachine(t *testing.T) *Machine {
+	m, err := New(WithRotors("III", "II", "I"), WithPositions("QEV"), WithPlugs("AB", "CD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// A Machine's Reader encrypts the same as EncryptBuffer,
+// and its Writer decrypts that again
+func TestStreamRoundTrip(t *testing.T) {
+	plaintext := strings.Repeat("DERFUEHRERISTTOT", 700)
+	want := string(streamMachine(t).EncryptBuffer([]rune(plaintext)))
+
+	ciphertext, err := io.ReadAll(NewReader(strings.NewReader(plaintext), streamMachine(t), DropNonLetters))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ciphertext) != want {
+		t.Fatal("Reader encrypts differently from EncryptBuffer")
+	}
+
+	var decrypted bytes.Buffer
+	w := NewWriter(&decrypted, streamMachine(t), DropNonLetters)
+	if _, err := w.Write(ciphertext); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.String() != plaintext {
+		t.Error("Writer doesn't decrypt what Reader encrypted")
+	}
+}
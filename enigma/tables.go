@@ -0,0 +1,133 @@
+package enigma
+
+import (
+	"enigmalike/rotor"
+	"fmt"
+	"sync"
+)
+
+// MaxPrecomputedStates is the most rotor (and stepping reflector)
+// positions Precompute makes a table for, the states of 4 rotors.
+// Each state takes 26 bytes.
+const MaxPrecomputedStates = 26 * 26 * 26 * 26
+
+// maxCachedBytes is how big the cached tables can get. A 3-rotor table
+// is about 450 KB, a 4-rotor table about 11.9 MB. The newest table
+// stays cached even if it's bigger on its own.
+const maxCachedBytes = 32 << 20
+
+// scramblerTables holds the scrambler permutation of every position
+// of one set of rotors, rings, reflector and entry wheel
+type scramblerTables struct {
+	once          sync.Once
+	permutations  []byte // 26 for each state, see index
+	withReflector bool   // the reflector steps, so its position is part of the state
+}
+
+// tableKey is everything but positions that changes a scrambler permutation
+type tableKey struct {
+	rotors         string // each rotor's wiring, then its ring letter
+	reflector      string
+	reflectorSteps int // -1 when the reflector steps and is part of the state
+	entry          *rotor.EntryWheel
+}
+
+// scramblerCache shares tables among all the Machines set up the same way
+var scramblerCache = struct {
+	sync.Mutex
+	tables map[tableKey]*scramblerTables
+	order  []tableKey // oldest first, to drop
+	sizes  []int      // bytes of each table in order
+	bytes  int        // of all the tables
+}{tables: make(map[tableKey]*scramblerTables)}
+
+// Precompute works out the scrambler permutation, everything between
+// the plugboard's contacts, for every position of the target
+// enigma.Machine's rotors, so that encrypting a letter is one lookup.
+// Machines with the same rotors, rings, reflector and entry wheel
+// share tables, so trying all the positions of one rotor order costs
+// one table. Setting the rings, reflector, entry wheel or stepping
+// drops the tables, call Precompute again after that.
+// It's an error for more than MaxPrecomputedStates positions.
+func (m *Machine) Precompute() error {
+	withReflector := m.stepping == CogStepping
+	states := 1
+	for range m.rotors {
+		states *= 26
+		if states > MaxPrecomputedStates {
+			return fmt.Errorf("%w: %d rotors", ErrTooManyStates, len(m.rotors))
+		}
+	}
+	if withReflector {
+		if states *= 26; states > MaxPrecomputedStates {
+			return fmt.Errorf("%w: %d rotors and a stepping reflector", ErrTooManyStates, len(m.rotors))
+		}
+	}
+
+	key := tableKey{reflector: m.reflector.Wiring(), reflectorSteps: -1, entry: m.entry}
+	if !withReflector {
+		key.reflectorSteps = m.reflector.Steps
+	}
+	for _, r := range m.rotors {
+		for _, out := range r.Encode {
+			key.rotors += string(rune(out + 'A'))
+		}
+		key.rotors += string(rune(r.Ring + 'A'))
+	}
+
+	scramblerCache.Lock()
+	t, ok := scramblerCache.tables[key]
+	if !ok {
+		t = &scramblerTables{withReflector: withReflector}
+		scramblerCache.tables[key] = t
+		scramblerCache.order = append(scramblerCache.order, key)
+		scramblerCache.sizes = append(scramblerCache.sizes, states*26)
+		scramblerCache.bytes += states * 26
+		for scramblerCache.bytes > maxCachedBytes && len(scramblerCache.order) > 1 {
+			delete(scramblerCache.tables, scramblerCache.order[0])
+			scramblerCache.bytes -= scramblerCache.sizes[0]
+			scramblerCache.order = scramblerCache.order[1:]
+			scramblerCache.sizes = scramblerCache.sizes[1:]
+		}
+	}
+	scramblerCache.Unlock()
+
+	t.once.Do(func() { t.fill(m, states) })
+	m.tables = t
+
+	return nil
+}
+
+// fill works out the permutation of each of states positions
+// on a Clone of m, so m doesn't move
+func (t *scramblerTables) fill(m *Machine, states int) {
+	c := m.Clone()
+	c.tables = nil
+	t.permutations = make([]byte, states*26)
+	for state := 0; state < states; state++ {
+		position := state
+		if t.withReflector {
+			c.reflector.Steps = position % 26
+			position /= 26
+		}
+		for _, r := range c.rotors {
+			r.Steps = position % 26
+			position /= 26
+		}
+		for contact := 0; contact < 26; contact++ {
+			t.permutations[state*26+contact] = byte(c.scramble(contact))
+		}
+	}
+}
+
+// permutation returns the scrambler permutation for m's current position
+func (t *scramblerTables) permutation(m *Machine) []byte {
+	state := 0
+	for i := len(m.rotors) - 1; i >= 0; i-- {
+		state = state*26 + m.rotors[i].Steps
+	}
+	if t.withReflector {
+		state = state*26 + m.reflector.Steps
+	}
+	return t.permutations[state*26 : state*26+26]
+}
@@ -0,0 +1,65 @@
+package enigma
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randomLetters(r *rand.Rand, n int) []rune {
+	letters := make([]rune, n)
+	for i := range letters {
+		letters[i] = rune('A' + r.Intn(26))
+	}
+	return letters
+}
+
+// Precompute's tables encrypt the same as the rotors, for every model
+func TestPrecompute(t *testing.T) {
+	text := randomLetters(rand.New(rand.NewSource(3)), 20000)
+	for name := range Models {
+		m, err := New(WithModel(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SetRingSettings("BCDE"[:len(m.rotors)]); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SetPositions("QEVZ"[:len(m.rotors)]); err != nil {
+			t.Fatal(err)
+		}
+		tabled := m.Clone()
+		if err := tabled.Precompute(); err != nil {
+			t.Fatal(err)
+		}
+		if string(m.EncryptBuffer(text)) != string(tabled.EncryptBuffer(text)) {
+			t.Errorf("model %s encrypts differently with Precompute", name)
+		}
+	}
+}
+
+func benchmarkEncryptBuffer(b *testing.B, precompute bool) {
+	text := randomLetters(rand.New(rand.NewSource(4)), 100000)
+	m, err := Build("B", "I", "II", "III")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if precompute {
+		if err := m.Precompute(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.SetRotors("AAA")
+		m.EncryptBuffer(text)
+	}
+}
+
+func BenchmarkEncryptBuffer(b *testing.B) {
+	benchmarkEncryptBuffer(b, false)
+}
+
+func BenchmarkEncryptBufferPrecomputed(b *testing.B) {
+	benchmarkEncryptBuffer(b, true)
+}
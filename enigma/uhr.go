@@ -0,0 +1,107 @@
+package enigma
+
+import (
+	"fmt"
+	"log"
+)
+
+/*
+The Enigma Uhr is a box that plugs into the plugboard in place of
+10 Stecker cables. Each of its 10 cables has an 'a' plug and a 'b' plug,
+and each plug has a big pin and a small pin. Current from the keyboard
+leaves through a big pin, current coming back from the rotors
+arrives through a small pin.
+
+Inside the box a disk, turned by the Uhr's switch to 40 positions,
+has 40 contacts on each face, wired from one face to the other.
+The big pins land on the even contacts of one face, the 'a' plugs'
+on 0, 4, 8... and the 'b' plugs' on 2, 6, 10..., and the small pins
+on the even contacts of the other face. Turning the disk an odd number
+of positions brings the odd contacts round to the pins.
+Coming back through the same disk wire is the inverse,
+so the forward and return substitutions differ, but undo each other.
+
+uhrDisk is the historical disk wiring, as Crypto Museum publishes it.
+At position 0 it takes each 'a' plug's big pin to its own 'b' plug's
+small pin and the other way around, which is exactly an ordinary
+Stecker cable, so that's where the small pins are.
+Other positions cross the cables over.
+*/
+
+// UhrPositions is how many positions the Uhr switch has
+const UhrPositions = 40
+
+// uhrDisk is the disk contact on the small pin face
+// wired to each contact on the big pin face
+var uhrDisk = [UhrPositions]int{
+	6, 31, 4, 29, 18, 39, 16, 25, 30, 23,
+	28, 1, 38, 11, 36, 37, 26, 27, 24, 21,
+	14, 3, 12, 17, 2, 7, 0, 33, 10, 35,
+	8, 5, 22, 19, 20, 13, 34, 15, 32, 9,
+}
+
+// Uhr plugs 10 pairs of letters into the Uhr instead of Stecker cables,
+// with the Uhr switch at setting, 0 through 39. The first letter of a pair
+// gets the 'a' plug, the second letter the 'b' plug.
+// Letters not in a pair go straight through, as with Plugboard.
+// It logs the problem and leaves the plugboard as it was
+// where SetUhr returns an error.
+func (m *Machine) Uhr(setting int, swaps ...string) {
+	if err := m.SetUhr(setting, swaps...); err != nil {
+		log.Print(err)
+	}
+}
+
+// SetUhr plugs in the Uhr like Uhr, returning an error for a bad
+// switch setting, other than 10 pairs, or the errors SetPlugboard returns.
+func (m *Machine) SetUhr(setting int, swaps ...string) error {
+	if setting < 0 || setting >= UhrPositions {
+		return fmt.Errorf("%w: Uhr switch %d", ErrBadSetting, setting)
+	}
+	if len(swaps) > 10 {
+		return fmt.Errorf("%w: %d, the Uhr takes 10", ErrTooManyCables, len(swaps))
+	}
+	if len(swaps) < 10 {
+		return fmt.Errorf("%w: %d, the Uhr takes 10", ErrTooFewCables, len(swaps))
+	}
+
+	// Where each plug's big pin lands on the disk,
+	// and whose small pin each contact on the other face is
+	var bigPin [26]int
+	smallPinLetter := make(map[int]int)
+	var plugged [26]bool
+
+	for i, swap := range swaps {
+		a, b, err := plugPair(swap)
+		if err != nil {
+			return err
+		}
+		if plugged[a] || plugged[b] {
+			return &PlugError{Plug: swap, Err: ErrLetterUsedTwice}
+		}
+		plugged[a], plugged[b] = true, true
+
+		bigPin[a] = 4 * i
+		bigPin[b] = 4*i + 2
+		smallPinLetter[uhrDisk[4*i]] = b
+		smallPinLetter[uhrDisk[4*i+2]] = a
+	}
+
+	for i := range m.plugBoard {
+		m.plugBoard[i] = i
+		m.plugReturn[i] = i
+	}
+
+	for letter := range m.plugBoard {
+		if !plugged[letter] {
+			continue
+		}
+		disk := (bigPin[letter] + setting) % UhrPositions
+		outer := (uhrDisk[disk] - setting + UhrPositions) % UhrPositions
+		out := smallPinLetter[outer]
+		m.plugBoard[letter] = out
+		m.plugReturn[out] = letter
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package enigma
+
+import "testing"
+
+var uhrPairs = []string{"AB", "CD", "EF", "GH", "IJ", "KL", "MN", "OP", "QR", "ST"}
+
+func uhrMachine(t *testing.T, setting int) *Machine {
+	m, err := Build("B", "III", "II", "I")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetPositions("QEV"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetUhr(setting, uhrPairs...); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestUhrReturnIsInverse(t *testing.T) {
+	for setting := 0; setting < UhrPositions; setting++ {
+		m := uhrMachine(t, setting)
+		for letter, out := range m.plugBoard {
+			if back := m.plugReturn[out]; back != letter {
+				t.Fatalf("Uhr %d: %c goes out to %c, which comes back to %c",
+					setting, 'A'+letter, 'A'+out, 'A'+back)
+			}
+		}
+	}
+}
+
+func TestUhrRoundTrip(t *testing.T) {
+	plaintext := []rune("ANXDASOBERKOMMANDODERWEHRMACHTXUHRGESTELLT")
+	for setting := 0; setting < UhrPositions; setting++ {
+		ciphertext := uhrMachine(t, setting).EncryptBuffer(plaintext)
+		if got := string(uhrMachine(t, setting).EncryptBuffer(ciphertext)); got != string(plaintext) {
+			t.Errorf("Uhr %d: %s decrypts to %s, want %s", setting, string(ciphertext), got, string(plaintext))
+		}
+	}
+}
+
+// At setting 0 the Uhr is the same as plain Stecker cables
+func TestUhrSettingZero(t *testing.T) {
+	m := uhrMachine(t, 0)
+	plugged, err := Build("B", "III", "II", "I")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plugged.SetPositions("QEV"); err != nil {
+		t.Fatal(err)
+	}
+	if err := plugged.SetPlugboard(uhrPairs...); err != nil {
+		t.Fatal(err)
+	}
+	if m.plugBoard != plugged.plugBoard || m.plugReturn != plugged.plugReturn {
+		t.Fatalf("Uhr 0 plugs %v back %v, cables plug %v back %v",
+			m.plugBoard, m.plugReturn, plugged.plugBoard, plugged.plugReturn)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 	"unicode"
 
 	"enigmalike/rotor"
@@ -18,7 +19,12 @@ func main() {
 	first := flag.String("1", "I", "first rotor")
 	second := flag.String("2", "II", "second rotor")
 	third := flag.String("3", "III", "third rotor")
-	settings := flag.String("S", "AAA", "initial rotor settings")
+	rotorList := flag.String("r", "", "comma-separated rotors, fast rotor first, any number, instead of -1, -2 and -3")
+	reflectorName := flag.String("U", "B", "reflector: A, B, C, B-thin or C-thin")
+	ukwd := flag.String("D", "", "comma-separated UKW-D wiring, 12 pairs not using J or Y, replaces -U")
+	settings := flag.String("S", "", "initial rotor settings, fast rotor first, A for any left out")
+	rings := flag.String("R", "", "rotor ring settings, fast rotor first, A for any left out")
+	legacy := flag.Bool("L", false, "legacy stepping, carry when a rotor wraps to 'A' instead of at its notch")
 	flag.Parse()
 
 	var cleartext string
@@ -36,44 +42,64 @@ func main() {
 		}
 	}
 
-	// set up rotors
-	var rotor1, rotor2, rotor3 *rotor.Rotor
-
-	if rotor1 = rotor.ChooseRotor(*first); rotor1 == nil {
-		log.Fatalf("no first rotor %q\n", *first)
+	// set up rotors, fast rotor first
+	names := []string{*first, *second, *third}
+	if *rotorList != "" {
+		names = strings.Split(*rotorList, ",")
 	}
-	if rotor2 = rotor.ChooseRotor(*second); rotor2 == nil {
-		log.Fatalf("no second rotor %q\n", *second)
+
+	var rotors []*rotor.Rotor
+	var reflector *rotor.Reflector
+
+	for i, name := range names {
+		r := rotor.ChooseRotor(name)
+		if r == nil {
+			log.Fatalf("no rotor %d %q\n", i+1, name)
+		}
+		r.WrapCarry = *legacy
+		rotors = append(rotors, r)
 	}
-	if rotor3 = rotor.ChooseRotor(*third); rotor3 == nil {
-		log.Fatalf("no third rotor %q\n", *third)
+	if *ukwd != "" {
+		var err error
+		if reflector, err = rotor.NewReflectorD(strings.Split(*ukwd, ",")...); err != nil {
+			log.Fatal(err)
+		}
+		*reflectorName = "D"
+	} else if reflector = rotor.ChooseReflector(*reflectorName); reflector == nil {
+		log.Fatalf("no reflector %q\n", *reflectorName)
 	}
 
 	for i, letter := range *settings {
 		setting := int(unicode.ToUpper(letter))
 		if setting < 'A' || setting > 'Z' {
-			fmt.Fprintf(os.Stderr, "Ignoring bad setting %c\n", setting)
-			continue
+			fmt.Fprintf(os.Stderr, "bad setting %c\n", setting)
+			os.Exit(1)
 		}
 		setting -= 'A'
-		switch i {
-		case 0:
-			rotor1.Steps = setting
-			if *verbose {
-				fmt.Fprintf(os.Stderr, "rotor 1 (fast) setting %c\n", setting+'A')
-			}
-		case 1:
-			rotor2.Steps = setting
-			if *verbose {
-				fmt.Fprintf(os.Stderr, "rotor 2 (medium) setting %c\n", setting+'A')
-			}
-		case 2:
-			rotor3.Steps = setting
-			if *verbose {
-				fmt.Fprintf(os.Stderr, "rotor 3 (slow) setting %c\n", setting+'A')
-			}
-		default:
-			fmt.Fprintf(os.Stderr, "unused rotor %d  setting %c\n", i+1, setting+'A')
+		if i >= len(rotors) {
+			fmt.Fprintf(os.Stderr, "no rotor %d for setting %c\n", i+1, setting+'A')
+			os.Exit(1)
+		}
+		rotors[i].Steps = setting
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "rotor %d setting %c\n", i+1, setting+'A')
+		}
+	}
+
+	for i, letter := range *rings {
+		ring := int(unicode.ToUpper(letter))
+		if ring < 'A' || ring > 'Z' {
+			fmt.Fprintf(os.Stderr, "bad ring setting %c\n", ring)
+			os.Exit(1)
+		}
+		ring -= 'A'
+		if i >= len(rotors) {
+			fmt.Fprintf(os.Stderr, "no rotor %d for ring setting %c\n", i+1, ring+'A')
+			os.Exit(1)
+		}
+		rotors[i].Ring = ring
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "rotor %d ring setting %c\n", i+1, ring+'A')
 		}
 	}
 
@@ -96,53 +122,33 @@ func main() {
 
 		// Give the input letter to the first rotor as a contact position,
 		// which is 0 for 'A', 1 for 'B', 2 for 'C', etc etc
-		outPos, carry := rotor1.CipherFwd(int(unicode.ToUpper(r)-'A'), rotate, *verbose)
+		// Each rotor's carry advances the next rotor.
+		outPos := int(unicode.ToUpper(r) - 'A')
+		carry := rotate
 
-		if *verbose {
-			fmt.Fprintf(os.Stderr, "first rotor output letter %c (%d), carry %d\n",
-				(outPos + 'A'), outPos, carry)
-		}
-
-		outPos, carry = rotor2.CipherFwd(outPos, carry, *verbose)
-
-		if *verbose {
-			fmt.Fprintf(os.Stderr, "second rotor output letter %c (%d), carry %d\n",
-				(outPos + 'A'), outPos, carry)
-		}
-
-		outPos, carry = rotor3.CipherFwd(outPos, carry, *verbose)
-
-		if *verbose {
-			fmt.Fprintf(os.Stderr, "third rotor output letter %c (%d), carry %d\n",
-				(outPos + 'A'), outPos, carry)
-		}
-
-		outPos = rotor.ReflectorB.Reflect(outPos)
+		for i, rotor := range rotors {
+			outPos, carry = rotor.CipherFwd(outPos, carry, *verbose)
 
-		if *verbose {
-			fmt.Fprintf(os.Stderr, "reflector B output letter %c (%d)\n",
-				(outPos + 'A'), outPos)
-		}
-
-		outPos = rotor3.CipherBkwd(outPos, *verbose)
-
-		if *verbose {
-			fmt.Fprintf(os.Stderr, "backward through third rotor output letter %c (%d)\n",
-				(outPos + 'A'), outPos)
+			if *verbose {
+				fmt.Fprintf(os.Stderr, "rotor %d output letter %c (%d), carry %d\n",
+					i+1, (outPos + 'A'), outPos, carry)
+			}
 		}
 
-		outPos = rotor2.CipherBkwd(outPos, *verbose)
+		outPos = reflector.Reflect(outPos)
 
 		if *verbose {
-			fmt.Fprintf(os.Stderr, "backward through second rotor output letter %c (%d)\n",
-				(outPos + 'A'), outPos)
+			fmt.Fprintf(os.Stderr, "reflector %s output letter %c (%d)\n",
+				*reflectorName, (outPos + 'A'), outPos)
 		}
 
-		outPos = rotor1.CipherBkwd(outPos, *verbose)
+		for i := len(rotors) - 1; i >= 0; i-- {
+			outPos = rotors[i].CipherBkwd(outPos, *verbose)
 
-		if *verbose {
-			fmt.Fprintf(os.Stderr, "backward through first rotor output letter %c (%d)\n",
-				(outPos + 'A'), outPos)
+			if *verbose {
+				fmt.Fprintf(os.Stderr, "backward through rotor %d output letter %c (%d)\n",
+					i+1, (outPos + 'A'), outPos)
+			}
 		}
 
 		fout.AddLetter(rune(outPos + 'A'))
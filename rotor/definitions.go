@@ -0,0 +1,120 @@
+package rotor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+A definitions file adds rotors, reflectors and entry wheels to
+Rotors, Reflectors and EntryWheels at run time. It's JSON:
+
+	{
+		"rotors": [
+			{"name": "IX", "wiring": "KPTYUELOCVGRFQDANJMBSWHZXI", "notches": "HN"}
+		],
+		"reflectors": [
+			{"name": "X", "wiring": "YRUHQSLDPXNGOKMIEBFZCWVJAT"}
+		],
+		"entry_wheels": [
+			{"name": "AZERTY", "wiring": "AZERTYUIOPQSDFGHJKLMWXCVBN"}
+		]
+	}
+
+Wirings list the letters that contacts 'A', 'B', 'C'... go to,
+the way this file's comments list the built-in wirings.
+*/
+
+// Definitions is the contents of a definitions file
+type Definitions struct {
+	Rotors      []Definition `json:"rotors"`
+	Reflectors  []Definition `json:"reflectors"`
+	EntryWheels []Definition `json:"entry_wheels"`
+}
+
+// Definition is one named wiring in a definitions file.
+// Only rotors have notches.
+type Definition struct {
+	Name    string `json:"name"`
+	Wiring  string `json:"wiring"`
+	Notches string `json:"notches,omitempty"`
+}
+
+// LoadDefinitions reads a definitions file, checks every wiring,
+// and adds them to Rotors, Reflectors and EntryWheels.
+// If anything is wrong it adds nothing.
+func LoadDefinitions(r io.Reader) error {
+	var defs Definitions
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&defs); err != nil {
+		return fmt.Errorf("definitions: %w", err)
+	}
+
+	return defs.Register()
+}
+
+// Register checks every wiring in defs and adds them to
+// Rotors, Reflectors and EntryWheels.
+// If anything is wrong it adds nothing.
+func (defs *Definitions) Register() error {
+	rotors := make(map[string]*Rotor)
+	for _, def := range defs.Rotors {
+		if err := checkName("rotor", def.Name, Rotors[def.Name] != nil || rotors[def.Name] != nil); err != nil {
+			return err
+		}
+		r, err := NewRotor(def.Wiring, def.Notches)
+		if err != nil {
+			return fmt.Errorf("rotor %q: %w", def.Name, err)
+		}
+		rotors[def.Name] = r
+	}
+
+	reflectors := make(map[string]*Reflector)
+	for _, def := range defs.Reflectors {
+		if err := checkName("reflector", def.Name, Reflectors[def.Name] != nil || reflectors[def.Name] != nil); err != nil {
+			return err
+		}
+		r, err := NewReflectorWiring(def.Wiring)
+		if err != nil {
+			return fmt.Errorf("reflector %q: %w", def.Name, err)
+		}
+		reflectors[def.Name] = r
+	}
+
+	entryWheels := make(map[string]*EntryWheel)
+	for _, def := range defs.EntryWheels {
+		if err := checkName("entry wheel", def.Name, EntryWheels[def.Name] != nil || entryWheels[def.Name] != nil); err != nil {
+			return err
+		}
+		e, err := NewEntryWheel(def.Wiring)
+		if err != nil {
+			return fmt.Errorf("entry wheel %q: %w", def.Name, err)
+		}
+		entryWheels[def.Name] = e
+	}
+
+	for name, r := range rotors {
+		Rotors[name] = r
+	}
+	for name, r := range reflectors {
+		Reflectors[name] = r
+	}
+	for name, e := range entryWheels {
+		EntryWheels[name] = e
+	}
+
+	return nil
+}
+
+func checkName(kind, name string, taken bool) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%s without a name", kind)
+	}
+	if taken {
+		return fmt.Errorf("%s %q already defined", kind, name)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package rotor
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Keys        = ABCDEFGHIJKLMNOPQRSTUVWXYZ
+              ||||||||||||||||||||||||||
+Entry ABC   = ABCDEFGHIJKLMNOPQRSTUVWXYZ (military machines)
+Entry QWERTZ= QWERTZUIOASDFGHJKPYXCVBNML (commercial D and K, Swiss-K)
+
+The entry wheel (Eintrittswalze) is the stationary wheel between the
+keyboard and plugboard and the fast rotor. The military machines wire
+key 'A' to the 'A' contact, key 'B' to 'B' and so on.
+The commercial machines wire the keys to the contacts in
+keyboard order: 'Q' to the 'A' contact, 'W' to 'B', 'E' to 'C'...
+*/
+
+type EntryWheel struct {
+	wiring  [26]int // key to contact
+	inverse [26]int // contact to key
+}
+
+// In goes from the position of a key (0 for 'A') to a contact position
+func (e *EntryWheel) In(key int) (contact int) {
+	return e.wiring[key]
+}
+
+// Out goes from a contact position back to the position of a key
+func (e *EntryWheel) Out(contact int) (key int) {
+	return e.inverse[contact]
+}
+
+// NewEntryWheel wires an entry wheel from the keys on contacts 'A', 'B', 'C'...,
+// "QWERTZUIOASDFGHJKPYXCVBNML" for the commercial machines.
+// Every key A-Z has to be on one contact.
+func NewEntryWheel(contacts string) (*EntryWheel, error) {
+	contacts = strings.ToUpper(contacts)
+	if len(contacts) != 26 {
+		return nil, fmt.Errorf("entry wheel wiring %q has %d letters, not 26", contacts, len(contacts))
+	}
+
+	e := &EntryWheel{}
+	var wired [26]bool
+	for contact, key := range contacts {
+		if key < 'A' || key > 'Z' || wired[key-'A'] {
+			return nil, fmt.Errorf("entry wheel wiring %q: bad or repeated letter %c", contacts, key)
+		}
+		wired[key-'A'] = true
+		e.wiring[key-'A'] = contact
+		e.inverse[contact] = int(key - 'A')
+	}
+	return e, nil
+}
+
+// mustEntryWheel is NewEntryWheel for the built-in wirings
+func mustEntryWheel(contacts string) *EntryWheel {
+	e, err := NewEntryWheel(contacts)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+var EntryWheelABC = mustEntryWheel("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+var EntryWheelQWERTZ = mustEntryWheel("QWERTZUIOASDFGHJKPYXCVBNML")
+
+var EntryWheels = map[string]*EntryWheel{
+	"ABC":    EntryWheelABC,
+	"QWERTZ": EntryWheelQWERTZ,
+}
+
+// ChooseEntryWheel returns an entry wheel it knows about,
+// otherwise nil. Entry wheels never change, so it isn't a copy.
+func ChooseEntryWheel(name string) *EntryWheel {
+	return EntryWheels[name]
+}
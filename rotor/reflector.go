@@ -1,26 +1,70 @@
 package rotor
 
+import (
+	"fmt"
+	"strings"
+)
+
 /*
 Contacts    = ABCDEFGHIJKLMNOPQRSTUVWXYZ
               ||||||||||||||||||||||||||
+Reflector A = EJMZALYXVBWFCRQUONTSPIKHGD
 Reflector B = YRUHQSLDPXNGOKMIEBFZCWVJAT
+Reflector C = FVPJIAOYEDRZXWGCTKUQSBNMHL
+B thin      = ENKQAUYWJICOPBLMDXZVFTHRGS
+C thin      = RDOBJNTKVEHMLFCWZAXGYIPSUQ
+K           = IMETCGFRAYSQBZXWLHKDVUPOJN (commercial D and K, Swiss-K)
+G           = RULQMZJSYGOCETKWDAHNBXPVIF (Abwehr Enigma G)
 
 Reflectors are just pairs of *contacts*.
 If contact for 'B' (position 1, 'A' -> 0) is energized,
 so is contact for 'R', and this goes both ways, an 'R'
 contact energized is a 'B' contact energized.
+
+The commercial machines let you turn the reflector like a rotor,
+and the Enigma G steps it like a rotor.
+Steps is how far it's turned, the same as Rotor.Steps.
+Military reflectors stay at 0.
 */
 
 type Reflector struct {
+	Steps  int
 	wiring [26]int
+	// Name is what ChooseReflector found the reflector under
+	// in Reflectors, empty for NewReflector and friends.
+	Name string
+}
+
+// Wiring returns the letters the reflector's contacts A through Z
+// connect to, at Steps 0, the way NewReflectorWiring takes them.
+func (r *Reflector) Wiring() string {
+	var letters [26]byte
+	for i, out := range r.wiring {
+		letters[i] = byte(out + 'A')
+	}
+	return string(letters[:])
 }
 
 // Reflect from in position to out position
 func (r *Reflector) Reflect(inPos int) (outPos int) {
-	outPos = r.wiring[inPos]
+	outPos = r.wiring[(inPos+r.Steps)%26] - r.Steps
+	if outPos < 0 {
+		outPos += 26
+	}
 	return
 }
 
+var ReflectorA = &Reflector{
+	wiring: [26]int{
+		'E' - 'A', 'J' - 'A', 'M' - 'A', 'Z' - 'A', 'A' - 'A',
+		'L' - 'A', 'Y' - 'A', 'X' - 'A', 'V' - 'A', 'B' - 'A',
+		'W' - 'A', 'F' - 'A', 'C' - 'A', 'R' - 'A', 'Q' - 'A',
+		'U' - 'A', 'O' - 'A', 'N' - 'A', 'T' - 'A', 'S' - 'A',
+		'P' - 'A', 'I' - 'A', 'K' - 'A', 'H' - 'A', 'G' - 'A',
+		'D' - 'A',
+	},
+}
+
 var ReflectorB = &Reflector{
 	wiring: [26]int{
 		'Y' - 'A', 'R' - 'A', 'U' - 'A', 'H' - 'A', 'Q' - 'A',
@@ -31,3 +75,154 @@ var ReflectorB = &Reflector{
 		'T' - 'A',
 	},
 }
+
+var ReflectorC = &Reflector{
+	wiring: [26]int{
+		'F' - 'A', 'V' - 'A', 'P' - 'A', 'J' - 'A', 'I' - 'A',
+		'A' - 'A', 'O' - 'A', 'Y' - 'A', 'E' - 'A', 'D' - 'A',
+		'R' - 'A', 'Z' - 'A', 'X' - 'A', 'W' - 'A', 'G' - 'A',
+		'C' - 'A', 'T' - 'A', 'K' - 'A', 'U' - 'A', 'Q' - 'A',
+		'S' - 'A', 'B' - 'A', 'N' - 'A', 'M' - 'A', 'H' - 'A',
+		'L' - 'A',
+	},
+}
+
+// ReflectorBThin and ReflectorCThin (B-Dünn and C-Dünn) are the thin
+// reflectors of the 4-rotor naval machine.
+var ReflectorBThin = &Reflector{
+	wiring: [26]int{
+		'E' - 'A', 'N' - 'A', 'K' - 'A', 'Q' - 'A', 'A' - 'A',
+		'U' - 'A', 'Y' - 'A', 'W' - 'A', 'J' - 'A', 'I' - 'A',
+		'C' - 'A', 'O' - 'A', 'P' - 'A', 'B' - 'A', 'L' - 'A',
+		'M' - 'A', 'D' - 'A', 'X' - 'A', 'Z' - 'A', 'V' - 'A',
+		'F' - 'A', 'T' - 'A', 'H' - 'A', 'R' - 'A', 'G' - 'A',
+		'S' - 'A',
+	},
+}
+
+var ReflectorCThin = &Reflector{
+	wiring: [26]int{
+		'R' - 'A', 'D' - 'A', 'O' - 'A', 'B' - 'A', 'J' - 'A',
+		'N' - 'A', 'T' - 'A', 'K' - 'A', 'V' - 'A', 'E' - 'A',
+		'H' - 'A', 'M' - 'A', 'L' - 'A', 'F' - 'A', 'C' - 'A',
+		'W' - 'A', 'Z' - 'A', 'A' - 'A', 'X' - 'A', 'G' - 'A',
+		'Y' - 'A', 'I' - 'A', 'P' - 'A', 'S' - 'A', 'U' - 'A',
+		'Q' - 'A',
+	},
+}
+
+var ReflectorK = &Reflector{
+	wiring: [26]int{
+		'I' - 'A', 'M' - 'A', 'E' - 'A', 'T' - 'A', 'C' - 'A',
+		'G' - 'A', 'F' - 'A', 'R' - 'A', 'A' - 'A', 'Y' - 'A',
+		'S' - 'A', 'Q' - 'A', 'B' - 'A', 'Z' - 'A', 'X' - 'A',
+		'W' - 'A', 'L' - 'A', 'H' - 'A', 'K' - 'A', 'D' - 'A',
+		'V' - 'A', 'U' - 'A', 'P' - 'A', 'O' - 'A', 'J' - 'A',
+		'N' - 'A',
+	},
+}
+
+var ReflectorG = &Reflector{
+	wiring: [26]int{
+		'R' - 'A', 'U' - 'A', 'L' - 'A', 'Q' - 'A', 'M' - 'A',
+		'Z' - 'A', 'J' - 'A', 'S' - 'A', 'Y' - 'A', 'G' - 'A',
+		'O' - 'A', 'C' - 'A', 'E' - 'A', 'T' - 'A', 'K' - 'A',
+		'W' - 'A', 'D' - 'A', 'A' - 'A', 'H' - 'A', 'N' - 'A',
+		'B' - 'A', 'X' - 'A', 'P' - 'A', 'V' - 'A', 'I' - 'A',
+		'F' - 'A',
+	},
+}
+
+var Reflectors = map[string]*Reflector{
+	"A":      ReflectorA,
+	"B":      ReflectorB,
+	"C":      ReflectorC,
+	"B-thin": ReflectorBThin,
+	"C-thin": ReflectorCThin,
+	"K":      ReflectorK,
+	"G":      ReflectorG,
+}
+
+// ChooseReflector returns a *copy* of a reflector it knows about,
+// otherwise nil
+func ChooseReflector(name string) *Reflector {
+	if model, ok := Reflectors[name]; ok {
+		r := &Reflector{}
+		_ = copy(r.wiring[:], model.wiring[:])
+		r.Name = name
+		return r
+	}
+	return nil
+}
+
+// NewReflector wires a reflector from 13 letter pairs, "AY", "BR" and so on.
+// Every letter A-Z has to appear in exactly one pair, so the reflector
+// maps every contact to another contact and back again.
+func NewReflector(pairs ...string) (*Reflector, error) {
+	if len(pairs) != 13 {
+		return nil, fmt.Errorf("reflector needs 13 pairs, not %d", len(pairs))
+	}
+
+	r := &Reflector{}
+	var wired [26]bool
+
+	for _, pair := range pairs {
+		pair = strings.ToUpper(pair)
+		if len(pair) != 2 || pair[0] < 'A' || pair[0] > 'Z' || pair[1] < 'A' || pair[1] > 'Z' {
+			return nil, fmt.Errorf("bad reflector pair %q", pair)
+		}
+		a, b := int(pair[0]-'A'), int(pair[1]-'A')
+		if a == b {
+			return nil, fmt.Errorf("reflector pair %q wires a letter to itself", pair)
+		}
+		for _, p := range []int{a, b} {
+			if wired[p] {
+				return nil, fmt.Errorf("reflector pair %q uses %c a second time", pair, p+'A')
+			}
+			wired[p] = true
+		}
+		r.wiring[a] = b
+		r.wiring[b] = a
+	}
+
+	return r, nil
+}
+
+// NewReflectorWiring wires a reflector from the letters that contacts
+// 'A', 'B', 'C'... go to, "YRUHQSLDPXNGOKMIEBFZCWVJAT" for reflector B.
+// If 'A' goes to 'Y', 'Y' has to go to 'A', and no letter can go to itself.
+func NewReflectorWiring(wiring string) (*Reflector, error) {
+	wiring = strings.ToUpper(wiring)
+	if len(wiring) != 26 {
+		return nil, fmt.Errorf("reflector wiring %q has %d letters, not 26", wiring, len(wiring))
+	}
+
+	var pairs []string
+	for i, letter := range wiring {
+		if letter < 'A' || letter > 'Z' {
+			return nil, fmt.Errorf("reflector wiring %q has bad letter %c", wiring, letter)
+		}
+		other := int(letter - 'A')
+		if wiring[other] != byte(i+'A') {
+			return nil, fmt.Errorf("reflector wiring %q: %c goes to %c, but %c doesn't come back",
+				wiring, i+'A', letter, letter)
+		}
+		if i < other {
+			pairs = append(pairs, string([]byte{byte(i + 'A'), byte(letter)}))
+		}
+	}
+
+	return NewReflector(pairs...)
+}
+
+// NewReflectorD wires the field-rewirable reflector UKW-D from 12 letter
+// pairs. UKW-D has one pair that can't be rewired, J and Y in Bletchley Park's
+// lettering of the contacts, so the 12 pairs wire the other 24 letters.
+func NewReflectorD(pairs ...string) (*Reflector, error) {
+	for _, pair := range pairs {
+		if strings.ContainsAny(strings.ToUpper(pair), "JY") {
+			return nil, fmt.Errorf("UKW-D pair %q: J and Y are fixed", pair)
+		}
+	}
+	return NewReflector(append([]string{"JY"}, pairs...)...)
+}
@@ -3,6 +3,7 @@ package rotor
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 // Representation of a rotor in an electro-mechanical encryption device.
@@ -21,51 +22,96 @@ type Rotor struct {
 	Steps   int
 	Encode  [26]int
 	Inverse [26]int
+	// Notches holds the positions (0 for 'A') at which stepping this
+	// rotor also steps the next rotor left. Rotor I has its notch at 'Q',
+	// so stepping from 'Q' to 'R' carries.
+	Notches []int
+	// WrapCarry is the original behavior of this emulator: carry only
+	// when the rotor wraps around to 'A', ignoring Notches.
+	WrapCarry bool
+	// Ring is the ring setting (Ringstellung), 0 for 'A'. It turns the
+	// wiring backwards relative to the alphabet ring, while Steps and
+	// Notches stay with the alphabet ring.
+	Ring int
+	// Name is what ChooseRotor found the rotor under in Rotors,
+	// empty for a rotor that didn't come from there.
+	Name string
 }
 
 func (r *Rotor) CipherFwd(inPos int, advance int, verbose bool) (outPos int, carry int) {
-	r.Steps = ((r.Steps + advance) % 26)
-	if r.Steps == 0 {
-		// this rotor has been stepped 26 times, next rotor left should step
-		carry = 1
-	}
+	carry = r.Advance(advance)
 
 	// find index of this rotor that corresponds to inPos.
-	// Since r.Steps is how far "ahead" this rotor is of
+	// Since the wiring is r.Steps less the ring setting "ahead" of
 	// the 0 in position, the index calculated is which index
 	// on this rotor corresponds to inPos
-	internalPos := ((inPos + r.Steps) % 26)
+	offset := r.offset()
+	internalPos := ((inPos + offset) % 26)
 
 	internalOutput := r.Encode[internalPos]
 
-	outPos = internalOutput - r.Steps
+	outPos = internalOutput - offset
 	if outPos < 0 {
 		outPos += 26
 	}
 
 	if verbose {
-		fmt.Fprintf(os.Stderr, "CipherFwd, in pos %d, steps %d, internal pos %d, internal out %d, out pos %d\n",
-			inPos, r.Steps, internalPos, internalOutput, outPos,
+		fmt.Fprintf(os.Stderr, "CipherFwd, in pos %d, steps %d, ring %d, internal pos %d, internal out %d, out pos %d\n",
+			inPos, r.Steps, r.Ring, internalPos, internalOutput, outPos,
 		)
 	}
 
 	return outPos, carry
 }
 
+// Advance steps the rotor advance positions, 0 or 1, and returns
+// the carry to the next rotor left, 1 if that rotor should step.
+func (r *Rotor) Advance(advance int) (carry int) {
+	atNotch := r.AtNotch()
+	r.Steps = ((r.Steps + advance) % 26)
+	if r.WrapCarry {
+		if advance > 0 && r.Steps == 0 {
+			// this rotor has been stepped 26 times, next rotor left should step
+			carry = 1
+		}
+	} else if advance > 0 && atNotch {
+		// this rotor stepped off a notch, next rotor left should step
+		carry = 1
+	}
+	return carry
+}
+
+// AtNotch reports whether the rotor sits at one of its notches,
+// that is, whether its next step would carry to the next rotor left.
+func (r *Rotor) AtNotch() bool {
+	for _, notch := range r.Notches {
+		if r.Steps == notch {
+			return true
+		}
+	}
+	return false
+}
+
+// Step advances the rotor one position, without any carry
+func (r *Rotor) Step() {
+	r.Steps = (r.Steps + 1) % 26
+}
+
 // CipherBkwd takes the input *position* (which is fixed in space,
 // figures out which rotor position that would match,
 // runs the input position through the rotor shuffling backwards,
 // and returns the output position
 func (r *Rotor) CipherBkwd(inPos int, verbose bool) (outPos int) {
 	// find index of this rotor that corresponds to inPos.
-	// Since r.Steps is how far "ahead" this rotor is of
+	// Since the wiring is r.Steps less the ring setting "ahead" of
 	// the 0 in position, the index calculated is which index
 	// on this rotor corresponds to inPos
-	internalPos := ((inPos + r.Steps) % 26) // LHS rotor contact
+	offset := r.offset()
+	internalPos := ((inPos + offset) % 26) // LHS rotor contact
 
 	internalOutput := r.Inverse[internalPos] // RHS rotor contact
 
-	outPos = internalOutput - r.Steps
+	outPos = internalOutput - offset
 	if outPos < 0 {
 		outPos += 26
 	}
@@ -73,6 +119,12 @@ func (r *Rotor) CipherBkwd(inPos int, verbose bool) (outPos int) {
 	return
 }
 
+// offset is how far the wiring has turned from the neutral setting:
+// steps forward, ring setting backward.
+func (r *Rotor) offset() int {
+	return (r.Steps - r.Ring + 26) % 26
+}
+
 /*
 Entry = ABCDEFGHIJKLMNOPQRSTUVWXYZ (rotor right side)
         ||||||||||||||||||||||||||
@@ -81,6 +133,34 @@ II    = AJDKSIRUXBLHWTMCQGZNPYFVOE
 III   = BDFHJLCPRTXVZNYEIWGAKMUSQO
 IV    = ESOVPZJAYQUIRHXLNFTGKDCMWB
 V     = VZBRGITYUPSDNHLXAWMJQOFECK
+VI    = JPGVOUMFYQBENHZRDKASXLICTW
+VII   = NZJHGRCXMYSWBOUFAIVLPEKQDT
+VIII  = FKQHTLXOCBJSPDZRAMEWNIUYGV
+
+Notches: I at Q, II at E, III at V, IV at J, V at Z,
+VI, VII and VIII, the naval rotors, at both Z and M
+
+Beta  = LEYJVCNIXWPBQMDRTAKZGFUHOS
+Gamma = FSOKANUERHMBTIYCWLQPZXVGJD
+
+Beta and Gamma are the thin "Greek" rotors of the 4-rotor naval machine.
+They have no notches and never step.
+
+Commercial Enigma D and K, and the Swiss-K, turn over at Y, E and N:
+
+K-I    = LPGSZMHAEOQKVXRFYBUTNICJDW
+K-II   = SLVGBTFXJQOHEWIRZYAMKPCNDU
+K-III  = CJGDPSHKTURAWZXFMYNQOBVLIE
+SK-I   = PEZUOHXSCVFMTBGLRINQJWAYDK
+SK-II  = ZOUESYDKFWPCIQXHMVBLGNJRAT
+SK-III = EHRVXGAOBQUSIMZFLYNWKTPDJC
+
+The Abwehr Enigma G rotors have many notches, 17, 15 and 11,
+the turnovers of the G-312 as Crypto Museum publishes them:
+
+G-I    = DMTWSILRUYQNKFEJCAZBPGXOHV notches ABCEFGIKLOPQSUVWZ
+G-II   = HQZGPJTMOBLNCIFDYAWVEUSRKX notches ACDFGHKMNQSTVYZ
+G-III  = UQNTLSZFMREHDPXKIBVYGJCWOA notches AEFHKMNRUWX
 */
 
 var RotorI = &Rotor{
@@ -100,6 +180,7 @@ var RotorI = &Rotor{
 		'R' - 'A', 'I' - 'A', 'N' - 'A', 'Q' - 'A', 'O' - 'A',
 		'J' - 'A',
 	},
+	Notches: []int{'Q' - 'A'},
 }
 var RotorII = &Rotor{
 	Encode: [26]int{
@@ -118,6 +199,7 @@ var RotorII = &Rotor{
 		'H' - 'A', 'X' - 'A', 'M' - 'A', 'I' - 'A', 'V' - 'A',
 		'S' - 'A',
 	},
+	Notches: []int{'E' - 'A'},
 }
 var RotorIII = &Rotor{
 	Encode: [26]int{
@@ -136,6 +218,7 @@ var RotorIII = &Rotor{
 		'W' - 'A', 'L' - 'A', 'R' - 'A', 'K' - 'A', 'O' - 'A',
 		'M' - 'A',
 	},
+	Notches: []int{'V' - 'A'},
 }
 var RotorIV = &Rotor{
 	Encode: [26]int{
@@ -154,6 +237,7 @@ var RotorIV = &Rotor{
 		'K' - 'A', 'D' - 'A', 'Y' - 'A', 'O' - 'A', 'I' - 'A',
 		'F' - 'A',
 	},
+	Notches: []int{'J' - 'A'},
 }
 var RotorV = &Rotor{
 	Encode: [26]int{
@@ -172,14 +256,328 @@ var RotorV = &Rotor{
 		'I' - 'A', 'A' - 'A', 'R' - 'A', 'P' - 'A', 'H' - 'A',
 		'B' - 'A',
 	},
+	Notches: []int{'Z' - 'A'},
+}
+var RotorVI = &Rotor{
+	Encode: [26]int{
+		'J' - 'A', 'P' - 'A', 'G' - 'A', 'V' - 'A', 'O' - 'A',
+		'U' - 'A', 'M' - 'A', 'F' - 'A', 'Y' - 'A', 'Q' - 'A',
+		'B' - 'A', 'E' - 'A', 'N' - 'A', 'H' - 'A', 'Z' - 'A',
+		'R' - 'A', 'D' - 'A', 'K' - 'A', 'A' - 'A', 'S' - 'A',
+		'X' - 'A', 'L' - 'A', 'I' - 'A', 'C' - 'A', 'T' - 'A',
+		'W' - 'A',
+	},
+	Inverse: [26]int{
+		'S' - 'A', 'K' - 'A', 'X' - 'A', 'Q' - 'A', 'L' - 'A',
+		'H' - 'A', 'C' - 'A', 'N' - 'A', 'W' - 'A', 'A' - 'A',
+		'R' - 'A', 'V' - 'A', 'G' - 'A', 'M' - 'A', 'E' - 'A',
+		'B' - 'A', 'J' - 'A', 'P' - 'A', 'T' - 'A', 'Y' - 'A',
+		'F' - 'A', 'D' - 'A', 'Z' - 'A', 'U' - 'A', 'I' - 'A',
+		'O' - 'A',
+	},
+	Notches: []int{'Z' - 'A', 'M' - 'A'},
+}
+var RotorVII = &Rotor{
+	Encode: [26]int{
+		'N' - 'A', 'Z' - 'A', 'J' - 'A', 'H' - 'A', 'G' - 'A',
+		'R' - 'A', 'C' - 'A', 'X' - 'A', 'M' - 'A', 'Y' - 'A',
+		'S' - 'A', 'W' - 'A', 'B' - 'A', 'O' - 'A', 'U' - 'A',
+		'F' - 'A', 'A' - 'A', 'I' - 'A', 'V' - 'A', 'L' - 'A',
+		'P' - 'A', 'E' - 'A', 'K' - 'A', 'Q' - 'A', 'D' - 'A',
+		'T' - 'A',
+	},
+	Inverse: [26]int{
+		'Q' - 'A', 'M' - 'A', 'G' - 'A', 'Y' - 'A', 'V' - 'A',
+		'P' - 'A', 'E' - 'A', 'D' - 'A', 'R' - 'A', 'C' - 'A',
+		'W' - 'A', 'T' - 'A', 'I' - 'A', 'A' - 'A', 'N' - 'A',
+		'U' - 'A', 'X' - 'A', 'F' - 'A', 'K' - 'A', 'Z' - 'A',
+		'O' - 'A', 'S' - 'A', 'L' - 'A', 'H' - 'A', 'J' - 'A',
+		'B' - 'A',
+	},
+	Notches: []int{'Z' - 'A', 'M' - 'A'},
+}
+var RotorVIII = &Rotor{
+	Encode: [26]int{
+		'F' - 'A', 'K' - 'A', 'Q' - 'A', 'H' - 'A', 'T' - 'A',
+		'L' - 'A', 'X' - 'A', 'O' - 'A', 'C' - 'A', 'B' - 'A',
+		'J' - 'A', 'S' - 'A', 'P' - 'A', 'D' - 'A', 'Z' - 'A',
+		'R' - 'A', 'A' - 'A', 'M' - 'A', 'E' - 'A', 'W' - 'A',
+		'N' - 'A', 'I' - 'A', 'U' - 'A', 'Y' - 'A', 'G' - 'A',
+		'V' - 'A',
+	},
+	Inverse: [26]int{
+		'Q' - 'A', 'J' - 'A', 'I' - 'A', 'N' - 'A', 'S' - 'A',
+		'A' - 'A', 'Y' - 'A', 'D' - 'A', 'V' - 'A', 'K' - 'A',
+		'B' - 'A', 'F' - 'A', 'R' - 'A', 'U' - 'A', 'H' - 'A',
+		'M' - 'A', 'C' - 'A', 'P' - 'A', 'L' - 'A', 'E' - 'A',
+		'W' - 'A', 'Z' - 'A', 'T' - 'A', 'G' - 'A', 'X' - 'A',
+		'O' - 'A',
+	},
+	Notches: []int{'Z' - 'A', 'M' - 'A'},
+}
+var RotorBeta = &Rotor{
+	Encode: [26]int{
+		'L' - 'A', 'E' - 'A', 'Y' - 'A', 'J' - 'A', 'V' - 'A',
+		'C' - 'A', 'N' - 'A', 'I' - 'A', 'X' - 'A', 'W' - 'A',
+		'P' - 'A', 'B' - 'A', 'Q' - 'A', 'M' - 'A', 'D' - 'A',
+		'R' - 'A', 'T' - 'A', 'A' - 'A', 'K' - 'A', 'Z' - 'A',
+		'G' - 'A', 'F' - 'A', 'U' - 'A', 'H' - 'A', 'O' - 'A',
+		'S' - 'A',
+	},
+	Inverse: [26]int{
+		'R' - 'A', 'L' - 'A', 'F' - 'A', 'O' - 'A', 'B' - 'A',
+		'V' - 'A', 'U' - 'A', 'X' - 'A', 'H' - 'A', 'D' - 'A',
+		'S' - 'A', 'A' - 'A', 'N' - 'A', 'G' - 'A', 'Y' - 'A',
+		'K' - 'A', 'M' - 'A', 'P' - 'A', 'Z' - 'A', 'Q' - 'A',
+		'W' - 'A', 'E' - 'A', 'J' - 'A', 'I' - 'A', 'C' - 'A',
+		'T' - 'A',
+	},
+}
+var RotorGamma = &Rotor{
+	Encode: [26]int{
+		'F' - 'A', 'S' - 'A', 'O' - 'A', 'K' - 'A', 'A' - 'A',
+		'N' - 'A', 'U' - 'A', 'E' - 'A', 'R' - 'A', 'H' - 'A',
+		'M' - 'A', 'B' - 'A', 'T' - 'A', 'I' - 'A', 'Y' - 'A',
+		'C' - 'A', 'W' - 'A', 'L' - 'A', 'Q' - 'A', 'P' - 'A',
+		'Z' - 'A', 'X' - 'A', 'V' - 'A', 'G' - 'A', 'J' - 'A',
+		'D' - 'A',
+	},
+	Inverse: [26]int{
+		'E' - 'A', 'L' - 'A', 'P' - 'A', 'Z' - 'A', 'H' - 'A',
+		'A' - 'A', 'X' - 'A', 'J' - 'A', 'N' - 'A', 'Y' - 'A',
+		'D' - 'A', 'R' - 'A', 'K' - 'A', 'F' - 'A', 'C' - 'A',
+		'T' - 'A', 'S' - 'A', 'I' - 'A', 'B' - 'A', 'M' - 'A',
+		'G' - 'A', 'W' - 'A', 'Q' - 'A', 'V' - 'A', 'O' - 'A',
+		'U' - 'A',
+	},
+}
+var RotorKI = &Rotor{
+	Encode: [26]int{
+		'L' - 'A', 'P' - 'A', 'G' - 'A', 'S' - 'A', 'Z' - 'A',
+		'M' - 'A', 'H' - 'A', 'A' - 'A', 'E' - 'A', 'O' - 'A',
+		'Q' - 'A', 'K' - 'A', 'V' - 'A', 'X' - 'A', 'R' - 'A',
+		'F' - 'A', 'Y' - 'A', 'B' - 'A', 'U' - 'A', 'T' - 'A',
+		'N' - 'A', 'I' - 'A', 'C' - 'A', 'J' - 'A', 'D' - 'A',
+		'W' - 'A',
+	},
+	Inverse: [26]int{
+		'H' - 'A', 'R' - 'A', 'W' - 'A', 'Y' - 'A', 'I' - 'A',
+		'P' - 'A', 'C' - 'A', 'G' - 'A', 'V' - 'A', 'X' - 'A',
+		'L' - 'A', 'A' - 'A', 'F' - 'A', 'U' - 'A', 'J' - 'A',
+		'B' - 'A', 'K' - 'A', 'O' - 'A', 'D' - 'A', 'T' - 'A',
+		'S' - 'A', 'M' - 'A', 'Z' - 'A', 'N' - 'A', 'Q' - 'A',
+		'E' - 'A',
+	},
+	Notches: []int{'Y' - 'A'},
+}
+var RotorKII = &Rotor{
+	Encode: [26]int{
+		'S' - 'A', 'L' - 'A', 'V' - 'A', 'G' - 'A', 'B' - 'A',
+		'T' - 'A', 'F' - 'A', 'X' - 'A', 'J' - 'A', 'Q' - 'A',
+		'O' - 'A', 'H' - 'A', 'E' - 'A', 'W' - 'A', 'I' - 'A',
+		'R' - 'A', 'Z' - 'A', 'Y' - 'A', 'A' - 'A', 'M' - 'A',
+		'K' - 'A', 'P' - 'A', 'C' - 'A', 'N' - 'A', 'D' - 'A',
+		'U' - 'A',
+	},
+	Inverse: [26]int{
+		'S' - 'A', 'E' - 'A', 'W' - 'A', 'Y' - 'A', 'M' - 'A',
+		'G' - 'A', 'D' - 'A', 'L' - 'A', 'O' - 'A', 'I' - 'A',
+		'U' - 'A', 'B' - 'A', 'T' - 'A', 'X' - 'A', 'K' - 'A',
+		'V' - 'A', 'J' - 'A', 'P' - 'A', 'A' - 'A', 'F' - 'A',
+		'Z' - 'A', 'C' - 'A', 'N' - 'A', 'H' - 'A', 'R' - 'A',
+		'Q' - 'A',
+	},
+	Notches: []int{'E' - 'A'},
+}
+var RotorKIII = &Rotor{
+	Encode: [26]int{
+		'C' - 'A', 'J' - 'A', 'G' - 'A', 'D' - 'A', 'P' - 'A',
+		'S' - 'A', 'H' - 'A', 'K' - 'A', 'T' - 'A', 'U' - 'A',
+		'R' - 'A', 'A' - 'A', 'W' - 'A', 'Z' - 'A', 'X' - 'A',
+		'F' - 'A', 'M' - 'A', 'Y' - 'A', 'N' - 'A', 'Q' - 'A',
+		'O' - 'A', 'B' - 'A', 'V' - 'A', 'L' - 'A', 'I' - 'A',
+		'E' - 'A',
+	},
+	Inverse: [26]int{
+		'L' - 'A', 'V' - 'A', 'A' - 'A', 'D' - 'A', 'Z' - 'A',
+		'P' - 'A', 'C' - 'A', 'G' - 'A', 'Y' - 'A', 'B' - 'A',
+		'H' - 'A', 'X' - 'A', 'Q' - 'A', 'S' - 'A', 'U' - 'A',
+		'E' - 'A', 'T' - 'A', 'K' - 'A', 'F' - 'A', 'I' - 'A',
+		'J' - 'A', 'W' - 'A', 'M' - 'A', 'O' - 'A', 'R' - 'A',
+		'N' - 'A',
+	},
+	Notches: []int{'N' - 'A'},
+}
+var RotorSKI = &Rotor{
+	Encode: [26]int{
+		'P' - 'A', 'E' - 'A', 'Z' - 'A', 'U' - 'A', 'O' - 'A',
+		'H' - 'A', 'X' - 'A', 'S' - 'A', 'C' - 'A', 'V' - 'A',
+		'F' - 'A', 'M' - 'A', 'T' - 'A', 'B' - 'A', 'G' - 'A',
+		'L' - 'A', 'R' - 'A', 'I' - 'A', 'N' - 'A', 'Q' - 'A',
+		'J' - 'A', 'W' - 'A', 'A' - 'A', 'Y' - 'A', 'D' - 'A',
+		'K' - 'A',
+	},
+	Inverse: [26]int{
+		'W' - 'A', 'N' - 'A', 'I' - 'A', 'Y' - 'A', 'B' - 'A',
+		'K' - 'A', 'O' - 'A', 'F' - 'A', 'R' - 'A', 'U' - 'A',
+		'Z' - 'A', 'P' - 'A', 'L' - 'A', 'S' - 'A', 'E' - 'A',
+		'A' - 'A', 'T' - 'A', 'Q' - 'A', 'H' - 'A', 'M' - 'A',
+		'D' - 'A', 'J' - 'A', 'V' - 'A', 'G' - 'A', 'X' - 'A',
+		'C' - 'A',
+	},
+	Notches: []int{'Y' - 'A'},
+}
+var RotorSKII = &Rotor{
+	Encode: [26]int{
+		'Z' - 'A', 'O' - 'A', 'U' - 'A', 'E' - 'A', 'S' - 'A',
+		'Y' - 'A', 'D' - 'A', 'K' - 'A', 'F' - 'A', 'W' - 'A',
+		'P' - 'A', 'C' - 'A', 'I' - 'A', 'Q' - 'A', 'X' - 'A',
+		'H' - 'A', 'M' - 'A', 'V' - 'A', 'B' - 'A', 'L' - 'A',
+		'G' - 'A', 'N' - 'A', 'J' - 'A', 'R' - 'A', 'A' - 'A',
+		'T' - 'A',
+	},
+	Inverse: [26]int{
+		'Y' - 'A', 'S' - 'A', 'L' - 'A', 'G' - 'A', 'D' - 'A',
+		'I' - 'A', 'U' - 'A', 'P' - 'A', 'M' - 'A', 'W' - 'A',
+		'H' - 'A', 'T' - 'A', 'Q' - 'A', 'V' - 'A', 'B' - 'A',
+		'K' - 'A', 'N' - 'A', 'X' - 'A', 'E' - 'A', 'Z' - 'A',
+		'C' - 'A', 'R' - 'A', 'J' - 'A', 'O' - 'A', 'F' - 'A',
+		'A' - 'A',
+	},
+	Notches: []int{'E' - 'A'},
+}
+var RotorSKIII = &Rotor{
+	Encode: [26]int{
+		'E' - 'A', 'H' - 'A', 'R' - 'A', 'V' - 'A', 'X' - 'A',
+		'G' - 'A', 'A' - 'A', 'O' - 'A', 'B' - 'A', 'Q' - 'A',
+		'U' - 'A', 'S' - 'A', 'I' - 'A', 'M' - 'A', 'Z' - 'A',
+		'F' - 'A', 'L' - 'A', 'Y' - 'A', 'N' - 'A', 'W' - 'A',
+		'K' - 'A', 'T' - 'A', 'P' - 'A', 'D' - 'A', 'J' - 'A',
+		'C' - 'A',
+	},
+	Inverse: [26]int{
+		'G' - 'A', 'I' - 'A', 'Z' - 'A', 'X' - 'A', 'A' - 'A',
+		'P' - 'A', 'F' - 'A', 'B' - 'A', 'M' - 'A', 'Y' - 'A',
+		'U' - 'A', 'Q' - 'A', 'N' - 'A', 'S' - 'A', 'H' - 'A',
+		'W' - 'A', 'J' - 'A', 'C' - 'A', 'L' - 'A', 'V' - 'A',
+		'K' - 'A', 'D' - 'A', 'T' - 'A', 'E' - 'A', 'R' - 'A',
+		'O' - 'A',
+	},
+	Notches: []int{'N' - 'A'},
+}
+var RotorGI = &Rotor{
+	Encode: [26]int{
+		'D' - 'A', 'M' - 'A', 'T' - 'A', 'W' - 'A', 'S' - 'A',
+		'I' - 'A', 'L' - 'A', 'R' - 'A', 'U' - 'A', 'Y' - 'A',
+		'Q' - 'A', 'N' - 'A', 'K' - 'A', 'F' - 'A', 'E' - 'A',
+		'J' - 'A', 'C' - 'A', 'A' - 'A', 'Z' - 'A', 'B' - 'A',
+		'P' - 'A', 'G' - 'A', 'X' - 'A', 'O' - 'A', 'H' - 'A',
+		'V' - 'A',
+	},
+	Inverse: [26]int{
+		'R' - 'A', 'T' - 'A', 'Q' - 'A', 'A' - 'A', 'O' - 'A',
+		'N' - 'A', 'V' - 'A', 'Y' - 'A', 'F' - 'A', 'P' - 'A',
+		'M' - 'A', 'G' - 'A', 'B' - 'A', 'L' - 'A', 'X' - 'A',
+		'U' - 'A', 'K' - 'A', 'H' - 'A', 'E' - 'A', 'C' - 'A',
+		'I' - 'A', 'Z' - 'A', 'D' - 'A', 'W' - 'A', 'J' - 'A',
+		'S' - 'A',
+	},
+	Notches: []int{'A' - 'A', 'B' - 'A', 'C' - 'A', 'E' - 'A', 'F' - 'A', 'G' - 'A', 'I' - 'A', 'K' - 'A', 'L' - 'A', 'O' - 'A', 'P' - 'A', 'Q' - 'A', 'S' - 'A', 'U' - 'A', 'V' - 'A', 'W' - 'A', 'Z' - 'A'},
+}
+var RotorGII = &Rotor{
+	Encode: [26]int{
+		'H' - 'A', 'Q' - 'A', 'Z' - 'A', 'G' - 'A', 'P' - 'A',
+		'J' - 'A', 'T' - 'A', 'M' - 'A', 'O' - 'A', 'B' - 'A',
+		'L' - 'A', 'N' - 'A', 'C' - 'A', 'I' - 'A', 'F' - 'A',
+		'D' - 'A', 'Y' - 'A', 'A' - 'A', 'W' - 'A', 'V' - 'A',
+		'E' - 'A', 'U' - 'A', 'S' - 'A', 'R' - 'A', 'K' - 'A',
+		'X' - 'A',
+	},
+	Inverse: [26]int{
+		'R' - 'A', 'J' - 'A', 'M' - 'A', 'P' - 'A', 'U' - 'A',
+		'O' - 'A', 'D' - 'A', 'A' - 'A', 'N' - 'A', 'F' - 'A',
+		'Y' - 'A', 'K' - 'A', 'H' - 'A', 'L' - 'A', 'I' - 'A',
+		'E' - 'A', 'B' - 'A', 'X' - 'A', 'W' - 'A', 'G' - 'A',
+		'V' - 'A', 'T' - 'A', 'S' - 'A', 'Z' - 'A', 'Q' - 'A',
+		'C' - 'A',
+	},
+	Notches: []int{'A' - 'A', 'C' - 'A', 'D' - 'A', 'F' - 'A', 'G' - 'A', 'H' - 'A', 'K' - 'A', 'M' - 'A', 'N' - 'A', 'Q' - 'A', 'S' - 'A', 'T' - 'A', 'V' - 'A', 'Y' - 'A', 'Z' - 'A'},
+}
+var RotorGIII = &Rotor{
+	Encode: [26]int{
+		'U' - 'A', 'Q' - 'A', 'N' - 'A', 'T' - 'A', 'L' - 'A',
+		'S' - 'A', 'Z' - 'A', 'F' - 'A', 'M' - 'A', 'R' - 'A',
+		'E' - 'A', 'H' - 'A', 'D' - 'A', 'P' - 'A', 'X' - 'A',
+		'K' - 'A', 'I' - 'A', 'B' - 'A', 'V' - 'A', 'Y' - 'A',
+		'G' - 'A', 'J' - 'A', 'C' - 'A', 'W' - 'A', 'O' - 'A',
+		'A' - 'A',
+	},
+	Inverse: [26]int{
+		'Z' - 'A', 'R' - 'A', 'W' - 'A', 'M' - 'A', 'K' - 'A',
+		'H' - 'A', 'U' - 'A', 'L' - 'A', 'Q' - 'A', 'V' - 'A',
+		'P' - 'A', 'E' - 'A', 'I' - 'A', 'C' - 'A', 'Y' - 'A',
+		'N' - 'A', 'B' - 'A', 'J' - 'A', 'F' - 'A', 'D' - 'A',
+		'A' - 'A', 'S' - 'A', 'X' - 'A', 'O' - 'A', 'T' - 'A',
+		'G' - 'A',
+	},
+	Notches: []int{'A' - 'A', 'E' - 'A', 'F' - 'A', 'H' - 'A', 'K' - 'A', 'M' - 'A', 'N' - 'A', 'R' - 'A', 'U' - 'A', 'W' - 'A', 'X' - 'A'},
 }
 
 var Rotors = map[string]*Rotor{
-	"I":   RotorI,
-	"II":  RotorII,
-	"III": RotorIII,
-	"IV":  RotorIV,
-	"V":   RotorV,
+	"I":      RotorI,
+	"II":     RotorII,
+	"III":    RotorIII,
+	"IV":     RotorIV,
+	"V":      RotorV,
+	"VI":     RotorVI,
+	"VII":    RotorVII,
+	"VIII":   RotorVIII,
+	"Beta":   RotorBeta,
+	"Gamma":  RotorGamma,
+	"K-I":    RotorKI,
+	"K-II":   RotorKII,
+	"K-III":  RotorKIII,
+	"SK-I":   RotorSKI,
+	"SK-II":  RotorSKII,
+	"SK-III": RotorSKIII,
+	"G-I":    RotorGI,
+	"G-II":   RotorGII,
+	"G-III":  RotorGIII,
+}
+
+// NewRotor wires a rotor from the letters that contacts 'A', 'B', 'C'...
+// go to, "EKMFLGDQVZNTOWYHXUSPAIBRCJ" for Rotor I, and its notch letters,
+// "Q" for Rotor I. The wiring has to use every letter A-Z once.
+func NewRotor(wiring, notches string) (*Rotor, error) {
+	wiring = strings.ToUpper(wiring)
+	if len(wiring) != 26 {
+		return nil, fmt.Errorf("rotor wiring %q has %d letters, not 26", wiring, len(wiring))
+	}
+
+	r := &Rotor{}
+	var wired [26]bool
+
+	for i, letter := range wiring {
+		if letter < 'A' || letter > 'Z' {
+			return nil, fmt.Errorf("rotor wiring %q has bad letter %c", wiring, letter)
+		}
+		out := int(letter - 'A')
+		if wired[out] {
+			return nil, fmt.Errorf("rotor wiring %q uses %c twice", wiring, letter)
+		}
+		wired[out] = true
+		r.Encode[i] = out
+		r.Inverse[out] = i
+	}
+
+	for _, letter := range strings.ToUpper(notches) {
+		if letter < 'A' || letter > 'Z' {
+			return nil, fmt.Errorf("bad notch %c", letter)
+		}
+		r.Notches = append(r.Notches, int(letter-'A'))
+	}
+
+	return r, nil
 }
 
 // ChooseRotor returns a *copy* of a rotor it knows about,
@@ -189,6 +587,8 @@ func ChooseRotor(name string) *Rotor {
 		r := &Rotor{}
 		_ = copy(r.Encode[:], model.Encode[:])
 		_ = copy(r.Inverse[:], model.Inverse[:])
+		r.Notches = append([]int(nil), model.Notches...)
+		r.Name = name
 		return r
 	}
 	return nil
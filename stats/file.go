@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+/*
+An n-gram tables file is
+
+	"NGRM"                   magic
+	version                  1 byte, 1
+	count                    1 byte, how many tables
+
+and count tables, each
+
+	N                        1 byte
+	Total                    8 bytes
+	LogProbability           26^N 4-byte floats
+
+all little-endian. Quadgrams make it about 1.8 MB.
+*/
+
+const (
+	fileMagic   = "NGRM"
+	fileVersion = 1
+)
+
+// Errors ReadTables wraps: ErrNotTables for input that doesn't start
+// like an n-gram tables file, ErrBadTables for one that does
+// but is truncated, another version, or otherwise broken
+var (
+	ErrNotTables = errors.New("not an n-gram tables file")
+	ErrBadTables = errors.New("bad n-gram tables file")
+)
+
+// WriteTables writes tables to w in the n-gram tables file format
+func WriteTables(w io.Writer, tables ...*Table) error {
+	if len(tables) > 255 {
+		return fmt.Errorf("%d tables is too many for one file", len(tables))
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString(fileMagic)
+	bw.Write([]byte{fileVersion, byte(len(tables))})
+	for _, t := range tables {
+		if t.N < 1 || t.N > MaxN || len(t.LogProbability) != tableSize(t.N) {
+			return fmt.Errorf("%d-gram table has %d entries", t.N, len(t.LogProbability))
+		}
+		bw.WriteByte(byte(t.N))
+		if err := binary.Write(bw, binary.LittleEndian, t.Total); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, t.LogProbability); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadTables reads tables WriteTables wrote
+func ReadTables(r io.Reader) ([]*Table, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(fileMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotTables
+		}
+		return nil, err
+	}
+	if string(magic) != fileMagic {
+		return nil, ErrNotTables
+	}
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadTables, io.ErrUnexpectedEOF)
+	}
+	if version := header[0]; version != fileVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrBadTables, version)
+	}
+
+	count := int(header[1])
+	var tables []*Table
+	for i := 0; i < count; i++ {
+		n, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadTables, io.ErrUnexpectedEOF)
+		}
+		if n < 1 || n > MaxN {
+			return nil, fmt.Errorf("%w: %d-gram table", ErrBadTables, n)
+		}
+		t := &Table{N: int(n), LogProbability: make([]float32, tableSize(int(n)))}
+		if err := binary.Read(br, binary.LittleEndian, &t.Total); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadTables, io.ErrUnexpectedEOF)
+		}
+		if err := binary.Read(br, binary.LittleEndian, t.LogProbability); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadTables, io.ErrUnexpectedEOF)
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// SaveTables writes tables to the named file
+func SaveTables(name string, tables ...*Table) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := WriteTables(f, tables...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadTables reads the tables in the named file
+func LoadTables(name string) ([]*Table, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tables, err := ReadTables(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return tables, nil
+}
+
+// Find returns the n-gram table of tables, nil if there isn't one
+func Find(tables []*Table, n int) *Table {
+	for _, t := range tables {
+		if t.N == n {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadTables(t *testing.T) {
+	tables, err := NewTables(Letters("Der Fuehrer ist tot, der Kampf geht weiter"), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file bytes.Buffer
+	if err := WriteTables(&file, tables...); err != nil {
+		t.Fatal(err)
+	}
+	written := file.Bytes()
+
+	read, err := ReadTables(bytes.NewReader(written))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, tables) {
+		t.Error("tables read back differ from the ones written")
+	}
+
+	wrongVersion := append([]byte(nil), written...)
+	wrongVersion[len(fileMagic)] = fileVersion + 1
+	tests := []struct {
+		name  string
+		input []byte
+		want  error
+	}{
+		{"plaintext", []byte("DERFUEHRERISTTOT"), ErrNotTables},
+		{"short plaintext", []byte("AB"), ErrNotTables},
+		{"truncated header", written[:len(fileMagic)+1], ErrBadTables},
+		{"truncated table", written[:len(written)-1], ErrBadTables},
+		{"wrong version", wrongVersion, ErrBadTables},
+	}
+	for _, tt := range tests {
+		_, err := ReadTables(bytes.NewReader(tt.input))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+		if tt.want == ErrBadTables && errors.Is(err, ErrNotTables) {
+			t.Errorf("%s: %v is ErrNotTables", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package stats
+
+/*
+Letter statistics of plaintext languages, for telling a right
+decryption from a wrong one: the index of coincidence, and tables
+of how likely each sequence of 1 through 4 letters, n-gram, is.
+*/
+
+import (
+	"enigmalike/alphabet"
+	"fmt"
+	"math"
+	"unicode"
+)
+
+// MaxN is the longest n-gram a Table can count, 26^4 entries
+const MaxN = 4
+
+// Letters converts text to the uppercase letters 'A' through 'Z' an
+// operator would have typed, spelling out umlauts and ß,
+// and dropping everything else
+func Letters(text string) []rune {
+	var letters []rune
+	for _, r := range text {
+		upper := unicode.ToUpper(r)
+		switch {
+		case upper >= 'A' && upper <= 'Z':
+			letters = append(letters, upper)
+		default:
+			for _, spelled := range alphabet.Spellings[upper] {
+				letters = append(letters, spelled)
+			}
+		}
+	}
+	return letters
+}
+
+// IndexOfCoincidence is the chance that two letters picked from text,
+// uppercase 'A' through 'Z', are the same,
+// about 0.038 for random letters, 0.066 for English, 0.076 for German
+func IndexOfCoincidence(text []rune) float64 {
+	var frequencies [26]int
+	for _, r := range text {
+		frequencies[r-'A']++
+	}
+	return coincidence(frequencies[:], len(text))
+}
+
+// UnicodeIndexOfCoincidence is IndexOfCoincidence of any text,
+// counting every Unicode letter, uppercased, and how many there are
+func UnicodeIndexOfCoincidence(text string) (int, float64) {
+	frequencies := make(map[rune]int)
+	N := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			frequencies[unicode.ToUpper(r)]++
+			N++
+		}
+	}
+	var counts []int
+	for _, freq := range frequencies {
+		counts = append(counts, freq)
+	}
+	return N, coincidence(counts, N)
+}
+
+// coincidence works out the index of coincidence from how many
+// times each letter came up, N letters in all
+func coincidence(frequencies []int, N int) float64 {
+	if N < 2 {
+		return 0
+	}
+	sum := 0
+	for _, freq := range frequencies {
+		sum += freq * (freq - 1)
+	}
+	return float64(sum) / float64(N*(N-1))
+}
+
+// Table has the log probabilities of every n-gram of some corpus
+// of a plaintext language, and scores text by them
+type Table struct {
+	N              int
+	Total          uint64    // n-grams counted
+	LogProbability []float32 // 26^N entries, "AA..." first, the first letter most significant
+}
+
+// NewTable counts the n-grams of corpus, uppercase letters 'A' through 'Z',
+// for n 1 through MaxN. N-grams the corpus hasn't got get a floor
+// probability, smaller than any it has.
+func NewTable(n int, corpus []rune) (*Table, error) {
+	if n < 1 || n > MaxN {
+		return nil, fmt.Errorf("can't count %d-grams, only 1 through %d", n, MaxN)
+	}
+
+	counts := make([]uint64, tableSize(n))
+	var total uint64
+	for i := 0; i+n <= len(corpus); i++ {
+		counts[index(corpus[i:i+n])]++
+		total++
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("corpus has no %d-grams", n)
+	}
+
+	t := &Table{N: n, Total: total, LogProbability: make([]float32, len(counts))}
+	floor := math.Log10(0.01 / float64(total))
+	for i, count := range counts {
+		t.LogProbability[i] = float32(floor)
+		if count > 0 {
+			t.LogProbability[i] = float32(math.Log10(float64(count) / float64(total)))
+		}
+	}
+	return t, nil
+}
+
+// NewTables counts the n-grams of corpus for each of sizes
+func NewTables(corpus []rune, sizes ...int) ([]*Table, error) {
+	var tables []*Table
+	for _, n := range sizes {
+		t, err := NewTable(n, corpus)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+func tableSize(n int) int {
+	size := 1
+	for i := 0; i < n; i++ {
+		size *= 26
+	}
+	return size
+}
+
+func index(letters []rune) int {
+	i := 0
+	for _, r := range letters {
+		i = i*26 + int(r-'A')
+	}
+	return i
+}
+
+// Score adds up the log probabilities of all of text's n-grams,
+// text being uppercase letters 'A' through 'Z'. Higher is more
+// like the corpus. Scores of texts the same length compare.
+func (t *Table) Score(text []rune) float64 {
+	if len(text) < t.N {
+		return 0
+	}
+	size := len(t.LogProbability)
+	i := index(text[:t.N-1])
+	score := 0.0
+	for _, r := range text[t.N-1:] {
+		i = (i*26 + int(r-'A')) % size
+		score += float64(t.LogProbability[i])
+	}
+	return score
+}
+
+// PerLetter is Score divided by how many n-grams text has,
+// which compares texts of different lengths
+func (t *Table) PerLetter(text []rune) float64 {
+	if len(text) < t.N {
+		return 0
+	}
+	return t.Score(text) / float64(len(text)-t.N+1)
+}
@@ -0,0 +1,233 @@
+package typex
+
+/*
+The British Typex is an Enigma-like rotor machine.
+Current from a key goes through an entry plugboard,
+two stators (rotors that never step), three stepping rotors,
+a reflector, and back out the same way.
+
+The entry plugboard wires each key to any contact, so unlike
+the Enigma Stecker it doesn't have to swap letters in pairs:
+the forward path uses the wiring and the return path its inverse.
+
+Typex rotors have several notches, so the stepping rotors turn
+over more often than Enigma rotors. The pawls work like the Enigma's,
+double step included.
+
+The service rotor wirings were never published. These are the
+example wirings the CyberChef Typex emulator ships with.
+*/
+
+import (
+	"enigmalike/enigma"
+	"enigmalike/rotor"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+)
+
+var rotorWirings = map[string]string{
+	"A": "MCYLPQUVRXGSAOWNBJEZDTFKHI",
+	"B": "KHWENRCBISXJQGOFMAPVYZDLTU",
+	"C": "BYPDZMGIKQCUSATREHOJNLFWXV",
+	"D": "ZANJCGDLVHIXOBRPMSWQUKFYET",
+	"E": "QXBGUTOVFCZPJIHSWERYNDAMLK",
+	"F": "BDCNWUEIQVFTSXALOGZJYMHKPR",
+	"G": "WJUKEIABMSGFTQZVCNPHORDXYL",
+	"H": "TNVCZXDIPFWQKHSJMAOYLEURGB",
+}
+
+// Every example rotor has the same notches
+const notches = "BFHNQUW"
+
+var reflectorPairs = []string{
+	"AN", "BC", "FG", "IE", "KD", "LU", "MH", "OR", "TS", "VZ", "WQ", "XJ", "YP",
+}
+
+// Rotors are the Typex rotors the package knows about, "A" through "H"
+var Rotors = map[string]*rotor.Rotor{}
+
+// Reflector is the Typex reflector
+var Reflector *rotor.Reflector
+
+func init() {
+	var err error
+	for name, wiring := range rotorWirings {
+		if Rotors[name], err = rotor.NewRotor(wiring, notches); err != nil {
+			panic(err)
+		}
+	}
+	if Reflector, err = rotor.NewReflector(reflectorPairs...); err != nil {
+		panic(err)
+	}
+}
+
+// ChooseRotor returns a *copy* of a Typex rotor it knows about,
+// otherwise nil
+func ChooseRotor(name string) *rotor.Rotor {
+	if model, ok := Rotors[name]; ok {
+		r := &rotor.Rotor{}
+		_ = copy(r.Encode[:], model.Encode[:])
+		_ = copy(r.Inverse[:], model.Inverse[:])
+		r.Notches = append([]int(nil), model.Notches...)
+		return r
+	}
+	return nil
+}
+
+// Stators is how many of a Machine's rotors, next to the entry plugboard, never step
+const Stators = 2
+
+type Machine struct {
+	// rotors[0] and rotors[1] are the stators, rotors[2] the fast rotor,
+	// rotors[4] the slow rotor next to the reflector
+	rotors    [5]*rotor.Rotor
+	reflector *rotor.Reflector
+	entry     [26]int // key to contact
+	inverse   [26]int // contact to key
+}
+
+// NewMachine arranges 5 Typex rotors, the two stators first,
+// then the fast, middle and slow rotors, but doesn't set them.
+// It logs the problem and returns nil where Build returns an error.
+func NewMachine(names ...string) *Machine {
+	m, err := Build(names...)
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return m
+}
+
+// Build arranges rotors like NewMachine, returning an error
+// for the wrong number of rotors, or an unknown or repeated rotor,
+// with the same errors as enigma.Build
+func Build(names ...string) (*Machine, error) {
+	m := &Machine{reflector: Reflector}
+
+	if len(names) != len(m.rotors) {
+		return nil, fmt.Errorf("%w: Typex needs %d rotors, not %d", enigma.ErrWrongPart, len(m.rotors), len(names))
+	}
+
+	for i, name := range names {
+		for j := 0; j < i; j++ {
+			if names[j] == name {
+				return nil, &enigma.RotorError{Position: i + 1, Name: name, Err: enigma.ErrDuplicateRotor}
+			}
+		}
+		if m.rotors[i] = ChooseRotor(name); m.rotors[i] == nil {
+			return nil, &enigma.RotorError{Position: i + 1, Name: name, Err: enigma.ErrUnknownRotor}
+		}
+	}
+
+	for i := range m.entry {
+		m.entry[i] = i
+		m.inverse[i] = i
+	}
+
+	return m, nil
+}
+
+// Plugboard wires the entry plugboard: key 'A' goes to the contact of
+// the first letter of wiring, key 'B' to the second and so on.
+// An empty wiring puts every key on its own contact.
+func (m *Machine) Plugboard(wiring string) error {
+	if wiring == "" {
+		wiring = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	}
+	wiring = strings.ToUpper(wiring)
+	if len(wiring) != 26 {
+		return fmt.Errorf("plugboard wiring %q has %d letters, not 26", wiring, len(wiring))
+	}
+
+	var entry, inverse [26]int
+	var wired [26]bool
+	for key, letter := range wiring {
+		if letter < 'A' || letter > 'Z' || wired[letter-'A'] {
+			return fmt.Errorf("plugboard wiring %q: bad or repeated letter %c", wiring, letter)
+		}
+		wired[letter-'A'] = true
+		entry[key] = int(letter - 'A')
+		inverse[letter-'A'] = key
+	}
+	m.entry, m.inverse = entry, inverse
+
+	return nil
+}
+
+// SetRotors turns the rotors to the letters of settings,
+// in the same order as NewMachine
+func (m *Machine) SetRotors(settings string) {
+	for i, r := range m.rotors {
+		r.Steps = 0
+		if i < len(settings) {
+			r.Steps = letterPosition(rune(settings[i]))
+		}
+	}
+}
+
+// SetRings sets the rings (alphabet tyres) of the rotors to the
+// letters of rings, in the same order as NewMachine
+func (m *Machine) SetRings(rings string) {
+	for i, r := range m.rotors {
+		r.Ring = 0
+		if i < len(rings) {
+			r.Ring = letterPosition(rune(rings[i]))
+		}
+	}
+}
+
+func letterPosition(letter rune) int {
+	letter = unicode.ToUpper(letter)
+	if letter < 'A' || letter > 'Z' {
+		log.Printf("Ignoring bad setting %c\n", letter)
+		return 0
+	}
+	return int(letter - 'A')
+}
+
+func (m *Machine) EncryptBuffer(text []rune) []rune {
+	var output []rune
+	for _, letter := range text {
+		if letter < 'A' || letter > 'Z' {
+			continue
+		}
+		output = append(output, m.EncryptLetter(letter))
+	}
+	return output
+}
+
+func (m *Machine) EncryptLetter(inLetter rune) rune {
+	m.step()
+
+	outPos := m.entry[int(unicode.ToUpper(inLetter)-'A')]
+
+	for _, r := range m.rotors {
+		outPos, _ = r.CipherFwd(outPos, 0, false)
+	}
+
+	outPos = m.reflector.Reflect(outPos)
+
+	for i := len(m.rotors) - 1; i >= 0; i-- {
+		outPos = m.rotors[i].CipherBkwd(outPos, false)
+	}
+
+	outPos = m.inverse[outPos]
+
+	return rune(outPos + 'A')
+}
+
+// step moves the three stepping rotors the way Enigma pawls do,
+// the middle rotor double stepping.
+func (m *Machine) step() {
+	fast, middle, slow := m.rotors[Stators], m.rotors[Stators+1], m.rotors[Stators+2]
+	fastAtNotch, middleAtNotch := fast.AtNotch(), middle.AtNotch()
+	if middleAtNotch {
+		slow.Step()
+	}
+	if fastAtNotch || middleAtNotch {
+		middle.Step()
+	}
+	fast.Step()
+}